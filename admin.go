@@ -0,0 +1,330 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Stats contains the statistics reported by the eventstore at /stats.
+//
+// Raw contains the full statistics document as returned by the server. It can
+// be used to access statistics that are not modelled by the types in this
+// package, which vary between server versions.
+type Stats struct {
+	Proc ProcessStats    `json:"proc"`
+	Sys  SystemStats     `json:"sys"`
+	ES   EventStoreStats `json:"es"`
+	Raw  json.RawMessage `json:"-"`
+}
+
+// ProcessStats contains statistics about the eventstore process.
+type ProcessStats struct {
+	StartTime            TimeStr     `json:"startTime"`
+	ID                   int         `json:"id"`
+	Mem                  int64       `json:"mem"`
+	CPU                  float64     `json:"cpu"`
+	CPUScaled            float64     `json:"cpuScaled"`
+	ThreadsCount         int         `json:"threadsCount"`
+	ContentionsRate      float64     `json:"contentionsRate"`
+	ThrownExceptionsRate float64     `json:"thrownExceptionsRate"`
+	DiskIO               DiskIOStats `json:"diskIo"`
+	TCP                  TCPStats    `json:"tcp"`
+}
+
+// DiskIOStats contains statistics about the disk io of the eventstore process.
+type DiskIOStats struct {
+	ReadBytes    int64 `json:"readBytes"`
+	WrittenBytes int64 `json:"writtenBytes"`
+	ReadOps      int64 `json:"readOps"`
+	WriteOps     int64 `json:"writeOps"`
+}
+
+// TCPStats contains statistics about the TCP connections to the eventstore.
+type TCPStats struct {
+	Connections               int     `json:"connections"`
+	ReceivingSpeed            float64 `json:"receivingSpeed"`
+	SendingSpeed              float64 `json:"sendingSpeed"`
+	InSend                    int64   `json:"inSend"`
+	MeasureTime               string  `json:"measureTime"`
+	PendingReceived           int64   `json:"pendingReceived"`
+	PendingSend               int64   `json:"pendingSend"`
+	ReceivedBytesSinceLastRun int64   `json:"receivedBytesSinceLastRun"`
+	ReceivedBytesTotal        int64   `json:"receivedBytesTotal"`
+	SentBytesSinceLastRun     int64   `json:"sentBytesSinceLastRun"`
+	SentBytesTotal            int64   `json:"sentBytesTotal"`
+}
+
+// SystemStats contains statistics about the machine hosting the eventstore.
+type SystemStats struct {
+	CPU     float64               `json:"cpu"`
+	FreeMem int64                 `json:"freeMem"`
+	Drive   map[string]DriveStats `json:"drive"`
+}
+
+// DriveStats contains statistics about a drive used by the eventstore.
+type DriveStats struct {
+	AvailableBytes int64  `json:"availableBytes"`
+	TotalBytes     int64  `json:"totalBytes"`
+	Usage          string `json:"usage"`
+	UsedBytes      int64  `json:"usedBytes"`
+}
+
+// EventStoreStats contains statistics about the internals of the eventstore.
+//
+// Queue contains the statistics for each of the eventstore's internal queues
+// keyed by queue name.
+type EventStoreStats struct {
+	Queue map[string]QueueStats `json:"queue"`
+}
+
+// QueueStats contains statistics about an internal queue of the eventstore.
+type QueueStats struct {
+	QueueName                 string  `json:"queueName"`
+	GroupName                 string  `json:"groupName"`
+	AvgItemsPerSecond         float64 `json:"avgItemsPerSecond"`
+	AvgProcessingTime         float64 `json:"avgProcessingTime"`
+	CurrentIdleTime           string  `json:"currentIdleTime"`
+	CurrentItemProcessingTime string  `json:"currentItemProcessingTime"`
+	IdleTimePercent           float64 `json:"idleTimePercent"`
+	Length                    int     `json:"length"`
+	LengthCurrentTryPeak      int     `json:"lengthCurrentTryPeak"`
+	LengthLifetimePeak        int     `json:"lengthLifetimePeak"`
+	TotalItemsProcessed       int64   `json:"totalItemsProcessed"`
+	InProgressMessage         string  `json:"inProgressMessage"`
+	LastProcessedMessage      string  `json:"lastProcessedMessage"`
+}
+
+// ServerInfo contains the information reported by the eventstore at /info.
+type ServerInfo struct {
+	ESVersion       string `json:"esVersion"`
+	State           string `json:"state"`
+	ProjectionsMode string `json:"projectionsMode"`
+}
+
+// Gossip contains the cluster membership reported by the eventstore at /gossip.
+type Gossip struct {
+	Members    []*MemberInfo `json:"members"`
+	ServerIP   string        `json:"serverIp"`
+	ServerPort int           `json:"serverPort"`
+}
+
+// MemberInfo describes a node in an eventstore cluster.
+type MemberInfo struct {
+	InstanceID         string  `json:"instanceId"`
+	TimeStamp          TimeStr `json:"timeStamp"`
+	State              string  `json:"state"`
+	IsAlive            bool    `json:"isAlive"`
+	ExternalTCPIP      string  `json:"externalTcpIp"`
+	ExternalTCPPort    int     `json:"externalTcpPort"`
+	ExternalHTTPIP     string  `json:"externalHttpIp"`
+	ExternalHTTPPort   int     `json:"externalHttpPort"`
+	LastCommitPosition int64   `json:"lastCommitPosition"`
+	WriterCheckpoint   int64   `json:"writerCheckpoint"`
+	ChaserCheckpoint   int64   `json:"chaserCheckpoint"`
+	EpochNumber        int     `json:"epochNumber"`
+	NodePriority       int     `json:"nodePriority"`
+}
+
+// ScavengeProgress describes the progress of a scavenge as recorded by the
+// eventstore in the $scavenges-{scavengeId} stream.
+type ScavengeProgress struct {
+	ScavengeID      string
+	Started         bool
+	Completed       bool
+	ChunksScavenged int
+	SpaceSaved      int64
+	Result          string
+	Error           string
+	TimeTaken       string
+}
+
+// AdminService provides methods for monitoring and operating the eventstore.
+//
+// Most operations require the credentials of a member of the $admins or
+// $ops groups.
+//
+// For more information see:
+// http://docs.geteventstore.com/http-api/3.8.0/
+type AdminService struct {
+	client *Client
+}
+
+// Admin returns an *AdminService for monitoring and operating the eventstore.
+func (c *Client) Admin() *AdminService {
+	return &AdminService{client: c}
+}
+
+// Stats returns the statistics of the eventstore node.
+func (a *AdminService) Stats(opts ...RequestOption) (*Stats, *Response, error) {
+	var b bytes.Buffer
+	resp, err := a.get("/stats", &b, opts)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	st := &Stats{}
+	if err := json.Unmarshal(b.Bytes(), st); err != nil {
+		return nil, resp, err
+	}
+	st.Raw = json.RawMessage(b.Bytes())
+
+	return st, resp, nil
+}
+
+// Info returns the version and state of the eventstore node.
+func (a *AdminService) Info(opts ...RequestOption) (*ServerInfo, *Response, error) {
+	var b bytes.Buffer
+	resp, err := a.get("/info", &b, opts)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	info := &ServerInfo{}
+	if err := json.NewDecoder(&b).Decode(info); err != nil {
+		return nil, resp, err
+	}
+
+	return info, resp, nil
+}
+
+// Ping checks that the eventstore node is up and able to serve requests.
+//
+// If the node is healthy the error returned will be nil. When the node is
+// starting up an ErrTemporarilyUnavailable will be returned.
+func (a *AdminService) Ping(opts ...RequestOption) (*Response, error) {
+	return a.get("/ping", nil, opts)
+}
+
+// Gossip returns the cluster membership as seen by the eventstore node.
+func (a *AdminService) Gossip(opts ...RequestOption) (*Gossip, *Response, error) {
+	var b bytes.Buffer
+	resp, err := a.get("/gossip", &b, opts)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	g := &Gossip{}
+	if err := json.NewDecoder(&b).Decode(g); err != nil {
+		return nil, resp, err
+	}
+
+	return g, resp, nil
+}
+
+// StartScavenge starts a scavenge of the eventstore database.
+//
+// The scavenge id is returned by servers that record scavenge progress in a
+// $scavenges stream and will be empty for servers that do not.
+func (a *AdminService) StartScavenge(opts ...RequestOption) (string, *Response, error) {
+	req, err := a.client.NewRequest(http.MethodPost, "/admin/scavenge", nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	applyOptions(req, opts)
+
+	var b bytes.Buffer
+	resp, err := a.client.Do(req, &b)
+	if err != nil {
+		return "", resp, err
+	}
+
+	if b.Len() == 0 {
+		return "", resp, nil
+	}
+
+	sr := struct {
+		ScavengeID string `json:"scavengeId"`
+	}{}
+	if err := json.NewDecoder(&b).Decode(&sr); err != nil {
+		return "", resp, err
+	}
+
+	return sr.ScavengeID, resp, nil
+}
+
+// StopScavenge stops the scavenge with the id provided.
+func (a *AdminService) StopScavenge(scavengeID string, opts ...RequestOption) (*Response, error) {
+	u := fmt.Sprintf("/admin/scavenge/%s", scavengeID)
+	req, err := a.client.NewRequest(http.MethodDelete, u, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.client.Do(req, nil)
+}
+
+// ScavengeProgress reads the $scavenges-{scavengeId} stream and returns the
+// progress of the scavenge.
+func (a *AdminService) ScavengeProgress(scavengeID string, opts ...RequestOption) (*ScavengeProgress, error) {
+	p := &ScavengeProgress{ScavengeID: scavengeID}
+	reader := a.client.NewStreamReader(fmt.Sprintf("$scavenges-%s", scavengeID), opts...)
+
+	for reader.Next() {
+		if err := reader.Err(); err != nil {
+			if _, ok := err.(*ErrNoMoreEvents); ok {
+				return p, nil
+			}
+			return nil, err
+		}
+
+		data := struct {
+			SpaceSaved int64  `json:"spaceSaved"`
+			TimeTaken  string `json:"timeTaken"`
+			Result     string `json:"result"`
+			Error      string `json:"error"`
+		}{}
+		if err := reader.Scan(&data, nil); err != nil {
+			return nil, err
+		}
+
+		switch reader.EventResponse().Event.EventType {
+		case "$scavengeStarted":
+			p.Started = true
+		case "$scavengeChunksCompleted":
+			p.ChunksScavenged++
+			p.SpaceSaved += data.SpaceSaved
+		case "$scavengeCompleted":
+			p.Completed = true
+			p.Result = data.Result
+			p.Error = data.Error
+			p.TimeTaken = data.TimeTaken
+			p.SpaceSaved = data.SpaceSaved
+		}
+	}
+
+	return p, nil
+}
+
+// Shutdown requests that the eventstore node shuts down.
+func (a *AdminService) Shutdown(opts ...RequestOption) (*Response, error) {
+	req, err := a.client.NewRequest(http.MethodPost, "/admin/shutdown", nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.client.Do(req, nil)
+}
+
+func (a *AdminService) get(path string, b *bytes.Buffer, opts []RequestOption) (*Response, error) {
+	req, err := a.client.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	applyOptions(req, opts)
+
+	if b == nil {
+		return a.client.Do(req, nil)
+	}
+	return a.client.Do(req, b)
+}
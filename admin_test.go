@@ -0,0 +1,188 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&AdminSuite{})
+
+type AdminSuite struct{}
+
+func (s *AdminSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *AdminSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+const statsJSON = `{
+  "proc": {
+    "startTime": "2016-06-29T09:50:22Z",
+    "id": 42,
+    "mem": 123456,
+    "cpu": 1.5,
+    "threadsCount": 30,
+    "diskIo": {"readBytes": 10, "writtenBytes": 20, "readOps": 1, "writeOps": 2},
+    "tcp": {"connections": 3, "receivedBytesTotal": 1024, "sentBytesTotal": 2048}
+  },
+  "sys": {
+    "cpu": 12.5,
+    "freeMem": 987654,
+    "drive": {"/data": {"availableBytes": 100, "totalBytes": 200, "usage": "50%", "usedBytes": 100}}
+  },
+  "es": {
+    "queue": {
+      "MainQueue": {"queueName": "MainQueue", "length": 4, "totalItemsProcessed": 1000}
+    }
+  }
+}`
+
+func (s *AdminSuite) TestStats(c *C) {
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodGet)
+		c.Assert(r.Header.Get("Accept"), Equals, "application/json")
+		fmt.Fprint(w, statsJSON)
+	})
+
+	st, _, err := client.Admin().Stats()
+	c.Assert(err, IsNil)
+	c.Assert(st.Proc.ID, Equals, 42)
+	c.Assert(st.Proc.DiskIO.WrittenBytes, Equals, int64(20))
+	c.Assert(st.Proc.TCP.Connections, Equals, 3)
+	c.Assert(st.Proc.TCP.SentBytesTotal, Equals, int64(2048))
+	c.Assert(st.Sys.Drive["/data"].Usage, Equals, "50%")
+	c.Assert(st.ES.Queue["MainQueue"].Length, Equals, 4)
+	c.Assert(st.ES.Queue["MainQueue"].TotalItemsProcessed, Equals, int64(1000))
+	c.Assert(string(st.Raw), Equals, statsJSON)
+}
+
+func (s *AdminSuite) TestStatsUnauthorized(c *C) {
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	st, _, err := client.Admin().Stats()
+	c.Assert(st, IsNil)
+	c.Assert(reflect.TypeOf(err).Elem().Name(), Equals, "ErrUnauthorized")
+}
+
+func (s *AdminSuite) TestInfo(c *C) {
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"esVersion": "3.9.3.0", "state": "master", "projectionsMode": "All"}`)
+	})
+
+	info, _, err := client.Admin().Info()
+	c.Assert(err, IsNil)
+	c.Assert(info.ESVersion, Equals, "3.9.3.0")
+	c.Assert(info.State, Equals, "master")
+	c.Assert(info.ProjectionsMode, Equals, "All")
+}
+
+func (s *AdminSuite) TestPing(c *C) {
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"text": "Ping request successfully handled"}`)
+	})
+
+	resp, err := client.Admin().Ping()
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *AdminSuite) TestPingWhenServerIsNotReady(c *C) {
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.Admin().Ping()
+	c.Assert(reflect.TypeOf(err).Elem().Name(), Equals, "ErrTemporarilyUnavailable")
+}
+
+func (s *AdminSuite) TestGossip(c *C) {
+	mux.HandleFunc("/gossip", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"members": [{"instanceId": "abc", "state": "Master", "isAlive": true, "externalHttpPort": 2113}], "serverIp": "127.0.0.1", "serverPort": 2113}`)
+	})
+
+	g, _, err := client.Admin().Gossip()
+	c.Assert(err, IsNil)
+	c.Assert(g.Members, HasLen, 1)
+	c.Assert(g.Members[0].State, Equals, "Master")
+	c.Assert(g.Members[0].IsAlive, Equals, true)
+	c.Assert(g.ServerPort, Equals, 2113)
+}
+
+func (s *AdminSuite) TestStartScavenge(c *C) {
+	mux.HandleFunc("/admin/scavenge", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodPost)
+		fmt.Fprint(w, `{"scavengeId": "some-scavenge"}`)
+	})
+
+	id, _, err := client.Admin().StartScavenge()
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, "some-scavenge")
+}
+
+func (s *AdminSuite) TestStartScavengeWithoutScavengeID(c *C) {
+	mux.HandleFunc("/admin/scavenge", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodPost)
+	})
+
+	id, _, err := client.Admin().StartScavenge()
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, "")
+}
+
+func (s *AdminSuite) TestStopScavenge(c *C) {
+	mux.HandleFunc("/admin/scavenge/some-scavenge", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodDelete)
+	})
+
+	_, err := client.Admin().StopScavenge("some-scavenge")
+	c.Assert(err, IsNil)
+}
+
+func (s *AdminSuite) TestScavengeProgress(c *C) {
+	stream := "$scavenges-some-scavenge"
+	data := []string{
+		`{"scavengeId": "some-scavenge"}`,
+		`{"spaceSaved": 100}`,
+		`{"spaceSaved": 50}`,
+		`{"result": "Success", "timeTaken": "00:00:01", "spaceSaved": 150}`,
+	}
+	types := []string{"$scavengeStarted", "$scavengeChunksCompleted", "$scavengeChunksCompleted", "$scavengeCompleted"}
+
+	es := []*mock.Event{}
+	for i, d := range data {
+		raw := json.RawMessage(d)
+		es = append(es, mock.CreateTestEvent(stream, server.URL, types[i], i, &raw, nil))
+	}
+	setupSimulator(es, nil)
+
+	p, err := client.Admin().ScavengeProgress("some-scavenge")
+	c.Assert(err, IsNil)
+	c.Assert(p.Started, Equals, true)
+	c.Assert(p.Completed, Equals, true)
+	c.Assert(p.ChunksScavenged, Equals, 2)
+	c.Assert(p.SpaceSaved, Equals, int64(150))
+	c.Assert(p.Result, Equals, "Success")
+	c.Assert(p.TimeTaken, Equals, "00:00:01")
+}
+
+func (s *AdminSuite) TestShutdown(c *C) {
+	mux.HandleFunc("/admin/shutdown", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodPost)
+	})
+
+	_, err := client.Admin().Shutdown()
+	c.Assert(err, IsNil)
+}
@@ -1,22 +1,30 @@
 package atom
 
 import (
+	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"strings"
 	"time"
 )
 
 // Feed represents an atom feed page from the eventstore.
+//
+// Feeds can be decoded from both the application/atom+xml and the
+// application/vnd.eventstore.atom+json formats served by the eventstore.
+// SelfURL and ETag are only available in the json format.
 type Feed struct {
-	XMLName      xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
-	Title        string   `xml:"title"`
-	ID           string   `xml:"id"`
-	StreamID     string   `xml:"streamId"`
-	HeadOfStream bool     `xml:"headOfStream"`
-	Link         []Link   `xml:"link"`
-	Updated      TimeStr  `xml:"updated"`
-	Author       *Person  `xml:"author"`
-	Entry        []*Entry `xml:"entry"`
+	XMLName      xml.Name `xml:"http://www.w3.org/2005/Atom feed" json:"-"`
+	Title        string   `xml:"title" json:"title"`
+	ID           string   `xml:"id" json:"id"`
+	StreamID     string   `xml:"streamId" json:"streamId"`
+	HeadOfStream bool     `xml:"headOfStream" json:"headOfStream"`
+	SelfURL      string   `xml:"-" json:"selfUrl,omitempty"`
+	ETag         string   `xml:"-" json:"eTag,omitempty"`
+	Link         []Link   `xml:"link" json:"links"`
+	Updated      TimeStr  `xml:"updated" json:"updated"`
+	Author       *Person  `xml:"author" json:"author"`
+	Entry        []*Entry `xml:"entry" json:"entries"`
 }
 
 // GetLink gets the link with the name specified by the link argument.
@@ -43,48 +51,305 @@ func (f *Feed) PrettyPrint() string {
 }
 
 // GetEventURLs extracts a slice of event urls from the feed object.
+//
+// An *ErrMalformedEntry is returned if the url of the event cannot be found for
+// any of the entries of the feed.
 func (f *Feed) GetEventURLs() ([]string, error) {
 	s := make([]string, len(f.Entry))
 	for i := 0; i < len(f.Entry); i++ {
-		e := f.Entry[i]
-		s[i] = strings.TrimRight(e.Link[1].Href, "/")
+		u, err := f.Entry[i].EventURL()
+		if err != nil {
+			return nil, err
+		}
+		s[i] = u
 	}
 	return s, nil
 }
 
 // Entry represents a feed entry.
+//
+// The fields from EventID onwards are only available when the feed is read in
+// the application/vnd.eventstore.atom+json format. Data, MetaData and
+// LinkMetaData are only included when the feed is requested with an embed
+// parameter. Depending on the embed mode and on whether the event is json they
+// contain either the json of the event or a json string holding the event
+// data, see EventData.
+//
+// When a stream of link events is read with links resolved, StreamID and
+// EventNumber are the position of the event in its original stream while
+// PositionStreamID and PositionEventNumber are the position of the link.
 type Entry struct {
-	Title     string  `xml:"title"`
-	ID        string  `xml:"id"`
-	Link      []Link  `xml:"link"`
-	Published TimeStr `xml:"published"`
-	Updated   TimeStr `xml:"updated"`
-	Author    *Person `xml:"author"`
-	Summary   *Text   `xml:"summary"`
-	Content   *Text   `xml:"content"`
+	Title               string          `xml:"title" json:"title"`
+	ID                  string          `xml:"id" json:"id"`
+	Link                []Link          `xml:"link" json:"links"`
+	Published           time.Time       `xml:"published" json:"published,omitempty"`
+	Updated             TimeStr         `xml:"updated" json:"updated"`
+	Author              *Person         `xml:"author" json:"author"`
+	Summary             *Text           `xml:"summary" json:"summary"`
+	Content             *Text           `xml:"content" json:"content,omitempty"`
+	EventID             string          `xml:"-" json:"eventId,omitempty"`
+	EventType           string          `xml:"-" json:"eventType,omitempty"`
+	EventNumber         int             `xml:"-" json:"eventNumber"`
+	Data                json.RawMessage `xml:"-" json:"data,omitempty"`
+	MetaData            json.RawMessage `xml:"-" json:"metaData,omitempty"`
+	LinkMetaData        json.RawMessage `xml:"-" json:"linkMetaData,omitempty"`
+	StreamID            string          `xml:"-" json:"streamId,omitempty"`
+	IsJSON              bool            `xml:"-" json:"isJson"`
+	IsMetaData          bool            `xml:"-" json:"isMetaData"`
+	IsLinkMetaData      bool            `xml:"-" json:"isLinkMetaData"`
+	PositionEventNumber int             `xml:"-" json:"positionEventNumber"`
+	PositionStreamID    string          `xml:"-" json:"positionStreamId,omitempty"`
+}
+
+// GetLink gets the link of the entry with the relation specified by the rel
+// argument. Nil is returned if the entry has no such link.
+func (e *Entry) GetLink(rel string) *Link {
+	if e == nil {
+		return nil
+	}
+
+	for _, v := range e.Link {
+		if v.Rel == rel {
+			return &v
+		}
+	}
+	return nil
+}
+
+// EventURL returns the url of the event of the entry.
+//
+// The url is taken from the alternate link of the entry or, if there is no
+// alternate link, from the edit link. An *ErrMalformedEntry is returned if the
+// entry has neither.
+func (e *Entry) EventURL() (string, error) {
+	l := e.GetLink("alternate")
+	if l == nil || l.Href == "" {
+		l = e.GetLink("edit")
+	}
+	if l == nil || l.Href == "" {
+		return "", &ErrMalformedEntry{ID: e.ID, Title: e.Title, Reason: "the entry has no alternate or edit link"}
+	}
+	return strings.TrimRight(l.Href, "/"), nil
+}
+
+// ErrMalformedEntry is returned when a feed entry does not contain the
+// information required to read its event.
+type ErrMalformedEntry struct {
+	ID     string
+	Title  string
+	Reason string
+}
+
+func (e ErrMalformedEntry) Error() string {
+	return fmt.Sprintf("Malformed feed entry %q: %s.", e.Title, e.Reason)
+}
+
+// EventData returns the data of the event embedded in the entry.
+//
+// Data embedded as a json string is unquoted. Nil is returned if no data was
+// embedded in the entry.
+func (e *Entry) EventData() ([]byte, error) {
+	return embedded(e.Data)
+}
+
+// EventMetaData returns the metadata of the event embedded in the entry.
+//
+// Metadata embedded as a json string is unquoted. Nil is returned if no
+// metadata was embedded in the entry.
+func (e *Entry) EventMetaData() ([]byte, error) {
+	return embedded(e.MetaData)
+}
+
+// embedded returns the bytes of embedded data, unquoting data that has been
+// embedded as a json string.
+func embedded(raw json.RawMessage) ([]byte, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if raw[0] != '"' {
+		return raw, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// entry has the fields of an Entry without its methods.
+type entry Entry
+
+// entryText is an Entry with the published time as the text used by the
+// eventstore.
+type entryText struct {
+	*entry
+	Published TimeStr `xml:"published" json:"published,omitempty"`
+}
+
+// newEntryText returns the entryText of the entry e.
+func newEntryText(e *Entry) *entryText {
+	t := &entryText{entry: (*entry)(e)}
+	if !e.Published.IsZero() {
+		t.Published = Time(e.Published)
+	}
+	return t
+}
+
+// published sets the published time of the entry from the decoded text.
+//
+// Like TimeStr, the text is not checked when it is decoded. Published is left
+// as the zero time if it is not a valid time.
+func (t *entryText) published() {
+	t.entry.Published, _ = t.Published.Time()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newEntryText(&e))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *Entry) UnmarshalJSON(b []byte) error {
+	t := newEntryText(e)
+	if err := json.Unmarshal(b, t); err != nil {
+		return err
+	}
+	t.published()
+	return nil
+}
+
+// MarshalXML implements xml.Marshaler.
+func (e Entry) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	return enc.EncodeElement(newEntryText(&e), start)
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (e *Entry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	t := newEntryText(e)
+	if err := d.DecodeElement(t, &start); err != nil {
+		return err
+	}
+	t.published()
+	return nil
+}
+
+// UpdatedTime returns the time the entry was updated as a time.Time.
+func (e *Entry) UpdatedTime() (time.Time, error) {
+	return e.Updated.Time()
 }
 
 // Link represents a Link entry in the feed.
 type Link struct {
-	Rel  string `xml:"rel,attr"`
-	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr" json:"relation"`
+	Href string `xml:"href,attr" json:"uri"`
 }
 
 // Person represents a person
 type Person struct {
-	Name string `xml:"name"`
+	Name string `xml:"name" json:"name"`
 }
 
 // Text represents a text entry
+//
+// In the json format text is represented as a json string.
 type Text struct {
 	Type string `xml:"type,attr,omitempty"`
 	Body string `xml:",chardata"`
 }
 
+// MarshalJSON implements json.Marshaler.
+func (t Text) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Body)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Text) UnmarshalJSON(b []byte) error {
+	t.Type = ""
+	return json.Unmarshal(b, &t.Body)
+}
+
 // TimeStr is a formatted time string
+//
+// The text of the time is kept as it is received from the server so that
+// sub-second precision and time zones are preserved. Use the Time method to
+// parse it as a time.Time.
 type TimeStr string
 
-// Time returns a TimeStr
+// timeLayout is the layout used to format times. Fractional seconds are only
+// included when they are not zero.
+const timeLayout = "2006-01-02T15:04:05.999999999-07:00"
+
+// timeLayouts are the layouts accepted when parsing times. The eventstore
+// returns times in RFC3339 format with fractional seconds, however, times
+// without a time zone are accepted and interpreted as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+}
+
+// Time returns a TimeStr version of the time.Time argument t.
 func Time(t time.Time) TimeStr {
-	return TimeStr(t.Format("2006-01-02T15:04:05-07:00"))
+	return TimeStr(t.Format(timeLayout))
+}
+
+// ParseTime parses a time in the format returned by the eventstore.
+func ParseTime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// Time parses the TimeStr as a time.Time.
+//
+// The zero time is returned for an empty TimeStr. The text is not checked when
+// it is decoded, so an error is returned here if it is not a valid time.
+func (t TimeStr) Time() (time.Time, error) {
+	if t == "" {
+		return time.Time{}, nil
+	}
+	return ParseTime(string(t))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t TimeStr) MarshalText() ([]byte, error) {
+	return []byte(t), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+//
+// The text is kept as it is, use the Time method to check that it is a valid
+// time.
+func (t *TimeStr) UnmarshalText(b []byte) error {
+	*t = TimeStr(strings.TrimSpace(string(b)))
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// A json null leaves the TimeStr unchanged and any other value that is not a
+// json string is kept as its raw text.
+func (t *TimeStr) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return t.UnmarshalText(b)
+	}
+	return t.UnmarshalText([]byte(s))
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (t *TimeStr) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	return t.UnmarshalText([]byte(s))
 }
@@ -0,0 +1,175 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator applies authentication to requests made to the eventstore.
+//
+// The client calls Authenticate on each request created by NewRequest before any
+// request options are applied. Implementations must be safe for concurrent use.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// BasicAuth authenticates requests using HTTP basic authentication.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate sets the basic authentication credentials on the request.
+func (a *BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// TokenRefresher returns a new bearer token and the time at which it expires.
+//
+// A zero expiry means that the token does not expire.
+type TokenRefresher func() (token string, expiry time.Time, err error)
+
+// BearerTokenAuth authenticates requests with a bearer token in the
+// Authorization header.
+//
+// This is typically used when the eventstore is deployed behind a reverse proxy
+// that authenticates requests.
+type BearerTokenAuth struct {
+	mu      sync.Mutex
+	token   string
+	expiry  time.Time
+	refresh TokenRefresher
+
+	// Leeway is subtracted from the token expiry so that the token is
+	// refreshed before it expires while a request is in flight.
+	Leeway time.Duration
+}
+
+// NewBearerTokenAuth returns a new *BearerTokenAuth.
+//
+// token is the initial token and may be empty, in which case refresh will be
+// called before the first request. refresh may be nil if the token never
+// needs to be refreshed.
+func NewBearerTokenAuth(token string, refresh TokenRefresher) *BearerTokenAuth {
+	return &BearerTokenAuth{
+		token:   token,
+		refresh: refresh,
+		Leeway:  10 * time.Second,
+	}
+}
+
+// Authenticate sets the bearer token on the request, refreshing the token first
+// if it has expired.
+func (a *BearerTokenAuth) Authenticate(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.refresh != nil && (a.token == "" || a.expired()) {
+		token, expiry, err := a.refresh()
+		if err != nil {
+			return err
+		}
+		a.token = token
+		a.expiry = expiry
+	}
+
+	if a.token == "" {
+		return fmt.Errorf("No bearer token is available.")
+	}
+
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+// Invalidate discards the current token so that it will be refreshed before
+// the next request.
+//
+// Invalidate is useful when the server returns an ErrUnauthorized for a token
+// that has been revoked before its expiry.
+func (a *BearerTokenAuth) Invalidate() {
+	a.mu.Lock()
+	a.token = ""
+	a.mu.Unlock()
+}
+
+func (a *BearerTokenAuth) expired() bool {
+	if a.expiry.IsZero() {
+		return false
+	}
+	return !time.Now().Before(a.expiry.Add(-a.Leeway))
+}
+
+// TrustedAuth authenticates requests using the eventstore ES-TrustedAuth header.
+//
+// Trusted authentication is intended for use by internal services where an
+// intermediary has already authenticated the user. The eventstore must be
+// configured to accept trusted authentication.
+type TrustedAuth struct {
+	Username string
+	Groups   []string
+}
+
+// Authenticate sets the ES-TrustedAuth header on the request.
+func (a *TrustedAuth) Authenticate(req *http.Request) error {
+	if a.Username == "" {
+		return fmt.Errorf("Trusted authentication requires a username.")
+	}
+
+	v := a.Username
+	if len(a.Groups) > 0 {
+		v = fmt.Sprintf("%s; %s", a.Username, strings.Join(a.Groups, ", "))
+	}
+	req.Header.Set("ES-TrustedAuth", v)
+	return nil
+}
+
+// ClientCertificateAuth authenticates with the server using TLS client
+// certificates.
+//
+// Certificates are presented during the TLS handshake so the *http.Client used
+// by the goes client must be configured with the TLS configuration. HTTPClient
+// returns a suitably configured client that can be passed to NewClient.
+//
+// Authenticate does not modify the request but returns an error if the request
+// would not be made over TLS.
+type ClientCertificateAuth struct {
+	Certificates []tls.Certificate
+	RootCAs      *x509.CertPool
+}
+
+// Authenticate returns an error if the request is not made over https.
+func (a *ClientCertificateAuth) Authenticate(req *http.Request) error {
+	if req.URL.Scheme != "https" {
+		return fmt.Errorf("Client certificate authentication requires https. URL scheme is %s.", req.URL.Scheme)
+	}
+	return nil
+}
+
+// TLSConfig returns a *tls.Config that presents the client certificates.
+func (a *ClientCertificateAuth) TLSConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: a.Certificates,
+		RootCAs:      a.RootCAs,
+	}
+}
+
+// HTTPClient returns an *http.Client that presents the client certificates.
+//
+// The transport is a copy of http.DefaultTransport so that it keeps the default
+// proxy settings, timeouts and HTTP/2 support.
+func (a *ClientCertificateAuth) HTTPClient() *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = a.TLSConfig()
+	return &http.Client{Transport: t}
+}
@@ -0,0 +1,166 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&AuthSuite{})
+
+type AuthSuite struct{}
+
+func (s *AuthSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *AuthSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+func (s *AuthSuite) TestBasicAuthAuthenticator(c *C) {
+	client.SetAuthenticator(&goes.BasicAuth{Username: "user", Password: "pass"})
+
+	req, err := client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, IsNil)
+	username, password, ok := req.BasicAuth()
+	c.Assert(ok, Equals, true)
+	c.Assert(username, Equals, "user")
+	c.Assert(password, Equals, "pass")
+}
+
+func (s *AuthSuite) TestSetAuthenticatorNilRemovesAuthentication(c *C) {
+	client.SetBasicAuth("user", "pass")
+	client.SetAuthenticator(nil)
+
+	req, err := client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("Authorization"), Equals, "")
+}
+
+func (s *AuthSuite) TestBearerTokenAuth(c *C) {
+	client.SetAuthenticator(goes.NewBearerTokenAuth("some-token", nil))
+
+	mux.HandleFunc("/streams/foo", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("Authorization"), Equals, "Bearer some-token")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req, err := client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, IsNil)
+	_, err = client.Do(req, nil)
+	c.Assert(err, IsNil)
+}
+
+// Tests that the refresh callback is used to acquire the first token and is
+// called again once the token has expired.
+func (s *AuthSuite) TestBearerTokenAuthRefreshesExpiredTokens(c *C) {
+	calls := 0
+	auth := goes.NewBearerTokenAuth("", func() (string, time.Time, error) {
+		calls++
+		if calls == 1 {
+			return "expired-token", time.Now().Add(-time.Minute), nil
+		}
+		return fmt.Sprintf("token-%d", calls), time.Now().Add(time.Hour), nil
+	})
+	client.SetAuthenticator(auth)
+
+	req, err := client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("Authorization"), Equals, "Bearer expired-token")
+
+	req, err = client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("Authorization"), Equals, "Bearer token-2")
+
+	req, err = client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("Authorization"), Equals, "Bearer token-2")
+	c.Assert(calls, Equals, 2)
+
+	auth.Invalidate()
+	req, err = client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("Authorization"), Equals, "Bearer token-3")
+}
+
+func (s *AuthSuite) TestBearerTokenAuthReturnsRefreshError(c *C) {
+	want := errors.New("token service unavailable")
+	client.SetAuthenticator(goes.NewBearerTokenAuth("", func() (string, time.Time, error) {
+		return "", time.Time{}, want
+	}))
+
+	req, err := client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, Equals, want)
+	c.Assert(req, IsNil)
+}
+
+func (s *AuthSuite) TestTrustedAuth(c *C) {
+	client.SetAuthenticator(&goes.TrustedAuth{Username: "ouro", Groups: []string{"$admins", "ops"}})
+
+	req, err := client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("ES-TrustedAuth"), Equals, "ouro; $admins, ops")
+	c.Assert(req.Header.Get("Authorization"), Equals, "")
+}
+
+func (s *AuthSuite) TestTrustedAuthWithoutGroups(c *C) {
+	client.SetAuthenticator(&goes.TrustedAuth{Username: "ouro"})
+
+	req, err := client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("ES-TrustedAuth"), Equals, "ouro")
+}
+
+func (s *AuthSuite) TestClientCertificateAuthRequiresHTTPS(c *C) {
+	client.SetAuthenticator(&goes.ClientCertificateAuth{})
+
+	_, err := client.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, NotNil)
+}
+
+func (s *AuthSuite) TestClientCertificateAuthHTTPClient(c *C) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+	auth := &goes.ClientCertificateAuth{RootCAs: roots}
+
+	tlsClient, err := goes.NewClient(auth.HTTPClient(), ts.URL)
+	c.Assert(err, IsNil)
+	tlsClient.SetAuthenticator(auth)
+
+	req, err := tlsClient.NewRequest(http.MethodGet, "/streams/foo", nil)
+	c.Assert(err, IsNil)
+	resp, err := tlsClient.Do(req, nil)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusNoContent)
+}
+
+// Tests that the transport of the client keeps the settings of the default
+// transport.
+func (s *AuthSuite) TestClientCertificateAuthHTTPClientKeepsDefaults(c *C) {
+	auth := &goes.ClientCertificateAuth{}
+	t, ok := auth.HTTPClient().Transport.(*http.Transport)
+	c.Assert(ok, Equals, true)
+
+	d := http.DefaultTransport.(*http.Transport)
+	c.Assert(t.Proxy, NotNil)
+	c.Assert(t.TLSHandshakeTimeout, Equals, d.TLSHandshakeTimeout)
+	c.Assert(t.IdleConnTimeout, Equals, d.IdleConnTimeout)
+	c.Assert(t.ForceAttemptHTTP2, Equals, true)
+	c.Assert(t.TLSClientConfig, NotNil)
+}
@@ -0,0 +1,299 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the responses to requests made by a Client.
+//
+// The eventstore marks full feed pages and events as cacheable as they never
+// change. When a cache is set on a Client with SetCache, these responses are
+// stored in the cache and later requests for the same resources are served from
+// the cache without a request to the server. Responses that the server marks as
+// not cacheable, such as the pages at the head of a stream, are never stored.
+//
+// Keys are hex strings. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the response stored for key, if any.
+	Get(key string) (*CachedResponse, bool)
+
+	// Set stores the response for key.
+	Set(key string, r *CachedResponse)
+
+	// Delete removes the response stored for key, if any.
+	Delete(key string)
+}
+
+// CachedResponse is a response stored in a Cache.
+//
+// The response can be served from the cache until Expires. After that the
+// response is revalidated with the server using its ETag if it has one.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Expires    time.Time
+}
+
+// fromCacheHeader is set on responses that were served from the cache.
+const fromCacheHeader = "X-From-Cache"
+
+// SetCache sets the cache used to store responses. Setting the cache to nil
+// disables caching.
+func (c *Client) SetCache(cache Cache) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache = cache
+}
+
+// cacheFor returns the cache and the cache key for the request, or a nil cache
+// if the request cannot be served from the cache.
+func (c *Client) cacheFor(req *http.Request) (Cache, string) {
+	c.mu.RLock()
+	cache := c.cache
+	c.mu.RUnlock()
+
+	if cache == nil || req.Method != http.MethodGet {
+		return nil, ""
+	}
+
+	// Requests that wait for new events at the head of a stream and requests
+	// that ask not to be served from a cache are always made to the server.
+	if req.Header.Get("ES-LongPoll") != "" || strings.Contains(req.Header.Get("Cache-Control"), "no-cache") {
+		return nil, ""
+	}
+	if isHeadPage(req) {
+		return nil, ""
+	}
+
+	// The key includes the headers that change the content of the response
+	// and the credentials as the response may only be visible to some users.
+	h := sha256.New()
+	h.Write([]byte(req.URL.String()))
+	for _, k := range []string{"Accept", "Authorization", "ES-TrustedAuth", "ES-ResolveLinkTos"} {
+		h.Write([]byte("\n" + k + ": " + req.Header.Get(k)))
+	}
+	return cache, hex.EncodeToString(h.Sum(nil))
+}
+
+// isHeadPage reports whether the request is for the feed page at the head of
+// a stream.
+func isHeadPage(req *http.Request) bool {
+	p := strings.TrimRight(req.URL.Path, "/")
+	if strings.Contains(p, "/head/") || strings.HasSuffix(p, "/head") {
+		return true
+	}
+	// The url of a stream without a version is the head of the stream.
+	parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	return len(parts) == 2 && parts[0] == "streams"
+}
+
+// cacheExpiry returns the time until which the response can be served from a
+// cache. ok is false if the response must not be cached.
+func cacheExpiry(resp *http.Response) (expires time.Time, ok bool) {
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, false
+	}
+
+	maxAge := -1
+	for _, d := range strings.Split(resp.Header.Get("Cache-Control"), ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		switch {
+		case d == "no-store", d == "no-cache", d == "private":
+			return time.Time{}, false
+		case strings.HasPrefix(d, "max-age="):
+			n, err := strconv.Atoi(strings.TrimPrefix(d, "max-age="))
+			if err != nil {
+				return time.Time{}, false
+			}
+			maxAge = n
+		}
+	}
+	if maxAge <= 0 {
+		return time.Time{}, false
+	}
+	return time.Now().Add(time.Duration(maxAge) * time.Second), true
+}
+
+// cachedHTTPResponse returns an *http.Response for a response stored in the
+// cache.
+func cachedHTTPResponse(req *http.Request, cr *CachedResponse) *http.Response {
+	header := make(http.Header, len(cr.Header)+1)
+	for k, v := range cr.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	header.Set(fromCacheHeader, "1")
+
+	return &http.Response{
+		Status:        strconv.Itoa(cr.StatusCode) + " " + http.StatusText(cr.StatusCode),
+		StatusCode:    cr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(cr.Body)),
+		ContentLength: int64(len(cr.Body)),
+		Request:       req,
+	}
+}
+
+// MemoryCache is a Cache that stores responses in memory.
+//
+// When the cache holds the maximum number of responses, the least recently used
+// response is removed to make room for a new one.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type memoryCacheEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+// NewMemoryCache returns a new *MemoryCache that holds up to maxEntries
+// responses.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Get returns the response stored for key, if any.
+func (m *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.lru.MoveToFront(e)
+	return e.Value.(*memoryCacheEntry).response, true
+}
+
+// Set stores the response for key.
+func (m *MemoryCache) Set(key string, r *CachedResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok {
+		e.Value.(*memoryCacheEntry).response = r
+		m.lru.MoveToFront(e)
+		return
+	}
+
+	m.entries[key] = m.lru.PushFront(&memoryCacheEntry{key: key, response: r})
+	for m.maxEntries > 0 && m.lru.Len() > m.maxEntries {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Delete removes the response stored for key, if any.
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok {
+		m.lru.Remove(e)
+		delete(m.entries, key)
+	}
+}
+
+// Len returns the number of responses in the cache.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// DiskCache is a Cache that stores responses as files in a directory.
+//
+// A DiskCache can be shared between runs of a program, for example so that
+// rebuilding a read model does not read the events of a stream from the server
+// again. Responses are not removed from the directory other than by Delete.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a new *DiskCache that stores responses in dir. The
+// directory is created if it does not exist.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get returns the response stored for key, if any.
+func (d *DiskCache) Get(key string) (*CachedResponse, bool) {
+	b, err := ioutil.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	r := &CachedResponse{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, false
+	}
+	return r, true
+}
+
+// Set stores the response for key.
+//
+// The response is written to a temporary file which is then renamed so that
+// a partially written response is never read.
+func (d *DiskCache) Set(key string, r *CachedResponse) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+
+	f, err := ioutil.TempFile(d.dir, key+".tmp")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+	if err := os.Rename(f.Name(), d.path(key)); err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// Delete removes the response stored for key, if any.
+func (d *DiskCache) Delete(key string) {
+	os.Remove(d.path(key))
+}
+
+func (d *DiskCache) path(key string) string {
+	return filepath.Join(d.dir, filepath.Base(key))
+}
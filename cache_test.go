@@ -0,0 +1,219 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&CacheSuite{})
+
+type CacheSuite struct{}
+
+func (s *CacheSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *CacheSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+// requestCounter counts the requests made to each path.
+type requestCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (r *requestCounter) inc(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts == nil {
+		r.counts = make(map[string]int)
+	}
+	r.counts[path]++
+}
+
+func (r *requestCounter) get(path string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counts[path]
+}
+
+// serveEvent serves an event at path with the Cache-Control header provided.
+func serveEvent(path, cacheControl string, counter *requestCounter) {
+	es := mock.CreateTestEvents(1, "SomeStream", server.URL, "FooEvent")
+	er, _ := mock.CreateTestEventAtomResponse(es[0], nil)
+	body := er.PrettyPrint()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		counter.inc(path)
+		w.Header().Set("Cache-Control", cacheControl)
+		fmt.Fprint(w, body)
+	})
+}
+
+func (s *CacheSuite) TestImmutableResponsesAreServedFromCache(c *C) {
+	var counter requestCounter
+	path := "/streams/SomeStream/0"
+	serveEvent(path, "max-age=31536000, public", &counter)
+	client.SetCache(goes.NewMemoryCache(100))
+
+	first, resp, err := client.GetEvent(path)
+	c.Assert(err, IsNil)
+	c.Assert(resp.Header.Get("X-From-Cache"), Equals, "")
+
+	second, resp, err := client.GetEvent(path)
+	c.Assert(err, IsNil)
+	c.Assert(resp.Header.Get("X-From-Cache"), Equals, "1")
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(second.PrettyPrint(), Equals, first.PrettyPrint())
+	c.Assert(counter.get(path), Equals, 1)
+
+	// Responses requested with different headers are cached separately.
+	_, _, err = client.GetEvent(path, goes.WithResolveLinkTos(false))
+	c.Assert(err, IsNil)
+	c.Assert(counter.get(path), Equals, 2)
+}
+
+func (s *CacheSuite) TestResponsesThatMustRevalidateAreNotCached(c *C) {
+	var counter requestCounter
+	path := "/streams/SomeStream/0"
+	serveEvent(path, "max-age=0, no-cache, must-revalidate", &counter)
+	client.SetCache(goes.NewMemoryCache(100))
+
+	for i := 0; i < 3; i++ {
+		_, _, err := client.GetEvent(path)
+		c.Assert(err, IsNil)
+	}
+	c.Assert(counter.get(path), Equals, 3)
+}
+
+// Tests that the page at the head of a stream is not cached even if the
+// server marks it as cacheable.
+func (s *CacheSuite) TestHeadPagesAreNotCached(c *C) {
+	var counter requestCounter
+	es := mock.CreateTestEvents(2, "SomeStream", server.URL, "FooEvent")
+	for _, path := range []string{"/streams/SomeStream", "/streams/SomeStream/head/backward/20"} {
+		f, _ := mock.CreateTestFeed(es, server.URL+path)
+		body := f.PrettyPrint()
+		p := path
+		mux.HandleFunc(p, func(w http.ResponseWriter, r *http.Request) {
+			counter.inc(p)
+			w.Header().Set("Cache-Control", "max-age=31536000, public")
+			fmt.Fprint(w, body)
+		})
+	}
+	client.SetCache(goes.NewMemoryCache(100))
+
+	for i := 0; i < 2; i++ {
+		_, _, err := client.ReadFeed("/streams/SomeStream")
+		c.Assert(err, IsNil)
+		_, _, err = client.ReadFeed("/streams/SomeStream/head/backward/20")
+		c.Assert(err, IsNil)
+	}
+	c.Assert(counter.get("/streams/SomeStream"), Equals, 2)
+	c.Assert(counter.get("/streams/SomeStream/head/backward/20"), Equals, 2)
+}
+
+// expiredCache stores responses that have already expired.
+type expiredCache struct {
+	*goes.MemoryCache
+}
+
+func (e expiredCache) Set(key string, r *goes.CachedResponse) {
+	cr := *r
+	cr.Expires = time.Now().Add(-time.Second)
+	e.MemoryCache.Set(key, &cr)
+}
+
+// Tests that an expired response is revalidated with its ETag and served from
+// the cache when the server returns 304 Not Modified.
+func (s *CacheSuite) TestExpiredResponsesAreRevalidated(c *C) {
+	path := "/streams/SomeStream/0"
+	es := mock.CreateTestEvents(1, "SomeStream", server.URL, "FooEvent")
+	er, _ := mock.CreateTestEventAtomResponse(es[0], nil)
+	body := er.PrettyPrint()
+
+	var counter requestCounter
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		counter.inc(path)
+		if r.Header.Get("If-None-Match") == `"0;1"` {
+			counter.inc("revalidated")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=31536000, public")
+		w.Header().Set("ETag", `"0;1"`)
+		fmt.Fprint(w, body)
+	})
+	client.SetCache(expiredCache{goes.NewMemoryCache(100)})
+
+	first, _, err := client.GetEvent(path)
+	c.Assert(err, IsNil)
+	second, resp, err := client.GetEvent(path)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(second.PrettyPrint(), Equals, first.PrettyPrint())
+	c.Assert(counter.get(path), Equals, 2)
+	c.Assert(counter.get("revalidated"), Equals, 1)
+}
+
+func (s *CacheSuite) TestMemoryCacheEvictsLeastRecentlyUsed(c *C) {
+	cache := goes.NewMemoryCache(2)
+	cache.Set("a", &goes.CachedResponse{Body: []byte("a")})
+	cache.Set("b", &goes.CachedResponse{Body: []byte("b")})
+	_, ok := cache.Get("a")
+	c.Assert(ok, Equals, true)
+
+	cache.Set("c", &goes.CachedResponse{Body: []byte("c")})
+	c.Assert(cache.Len(), Equals, 2)
+	_, ok = cache.Get("b")
+	c.Assert(ok, Equals, false)
+	_, ok = cache.Get("a")
+	c.Assert(ok, Equals, true)
+
+	cache.Delete("a")
+	_, ok = cache.Get("a")
+	c.Assert(ok, Equals, false)
+}
+
+func (s *CacheSuite) TestDiskCache(c *C) {
+	dir, err := ioutil.TempDir("", "goes-cache")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+
+	cache, err := goes.NewDiskCache(dir)
+	c.Assert(err, IsNil)
+
+	want := &goes.CachedResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Etag": []string{`"0;1"`}},
+		Body:       []byte(`{"foo":"bar"}`),
+		Expires:    time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	cache.Set("abc123", want)
+
+	// The response is read by a new cache using the same directory.
+	cache, err = goes.NewDiskCache(dir)
+	c.Assert(err, IsNil)
+	got, ok := cache.Get("abc123")
+	c.Assert(ok, Equals, true)
+	c.Assert(got.StatusCode, Equals, want.StatusCode)
+	c.Assert(got.Header, DeepEquals, want.Header)
+	c.Assert(got.Body, DeepEquals, want.Body)
+	c.Assert(got.Expires.Equal(want.Expires), Equals, true)
+
+	cache.Delete("abc123")
+	_, ok = cache.Get("abc123")
+	c.Assert(ok, Equals, false)
+}
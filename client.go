@@ -15,6 +15,9 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/jetbasrawi/go.geteventstore/atom"
 )
@@ -42,22 +45,44 @@ type Response struct {
 // http.Request that resulted in an error.
 // Status contains the status message returned from the server.
 // StatusCode contains the status code returned from the server.
+// Reason contains the reason phrase of the status, which the eventstore uses to
+// describe the error, for example "Wrong expected EventNumber".
+// Message contains the body of the response returned from the server.
+// StreamName and Operation describe the request to a stream that failed, such as
+// "read" or "append". They are empty for requests that are not made to a stream.
 type ErrorResponse struct {
 	*http.Response
 	Request    *http.Request
 	Status     string
 	StatusCode int
+	Reason     string
+	Message    string
+	StreamName string
+	Operation  string
 }
 
 func (r *ErrorResponse) Error() string {
-	return fmt.Sprintf("%v %v: %d %+v",
-		r.Response.Request.Method, r.Response.Request.URL,
-		r.Response.StatusCode, r.Status)
+	return fmt.Sprintf("%v %v: %s", r.Request.Method, r.Request.URL, r.details())
 }
 
-type basicAuthCredentials struct {
-	Username string
-	Password string
+// maxErrorMessage is the number of bytes of the response body included in the
+// text of an error.
+const maxErrorMessage = 512
+
+// details returns a description of the error response for use in the text of
+// errors.
+func (r *ErrorResponse) details() string {
+	d := fmt.Sprintf("%d %s", r.StatusCode, r.Reason)
+	if r.Operation != "" {
+		d = fmt.Sprintf("%s %q: %s", r.Operation, r.StreamName, d)
+	}
+	if m := strings.TrimSpace(r.Message); m != "" {
+		if len(m) > maxErrorMessage {
+			m = m[:maxErrorMessage] + "..."
+		}
+		d += ": " + m
+	}
+	return d
 }
 
 // Client is the interface that the client should implement
@@ -65,6 +90,7 @@ type basicAuthCredentials struct {
 // 	NewStreamReader(streamName string) *StreamReader
 // 	NewStreamWriter(streamName string) *StreamWriter
 // 	SetBasicAuth(username, password string)
+// 	SetAuthenticator(a Authenticator)
 // 	NewRequest(method, urlString string, body interface{}) (*http.Request, error)
 // 	Do(req *http.Request, v io.Writer) (*Response, error)
 // 	GetEvent(url string) (*EventResponse, *Response, error)
@@ -77,7 +103,7 @@ type basicAuthCredentials struct {
 // Client is a handle for an eventstore server.
 //
 // The client is used to store connection details such as server URL,
-// basic authentication credentials and headers.
+// authentication and headers.
 //
 // The client also provides methods interacting with the eventstore.
 //
@@ -85,11 +111,23 @@ type basicAuthCredentials struct {
 // interact with the eventstore. These methods further abstract methods
 // on the client, however you can also directly use methods on the client
 // to interact with the eventstore if you want to create some custom behaviour.
+//
+// A Client is safe for concurrent use by multiple goroutines. Credentials and
+// headers may be changed while requests are in flight; each request is built
+// from a consistent snapshot of the client configuration.
 type Client struct {
-	client      *http.Client
-	baseURL     *url.URL
-	credentials *basicAuthCredentials
-	headers     map[string]string
+	client        *http.Client
+	baseURL       *url.URL
+	mu            sync.RWMutex
+	authenticator Authenticator
+	headers       map[string]string
+	cache         Cache
+
+	disableCompression     bool
+	requestCompressionSize int
+	stats                  *TransferStats
+
+	maxResponseSize int64
 }
 
 // NewClient returns a new client.
@@ -113,51 +151,54 @@ func NewClient(httpClient *http.Client, serverURL string) (*Client, error) {
 		client:  httpClient,
 		baseURL: baseURL,
 		headers: make(map[string]string),
+		stats:   &TransferStats{},
 	}
 	return c, nil
 }
 
-func (c *Client) copy() *Client {
-	client := &Client{
-		client:      c.client,
-		baseURL:     c.baseURL,
-		credentials: c.credentials,
-		headers:     make(map[string]string),
-	}
-	for k, v := range c.headers {
-		client.headers[k] = v
-	}
-	return client
-}
-
 // NewStreamReader returns a new *StreamReader.
-func (c *Client) NewStreamReader(streamName string) *StreamReader {
-	sr := &StreamReader{
+//
+// Any options provided will be applied to every request made by the reader.
+func (c *Client) NewStreamReader(streamName string, opts ...RequestOption) *StreamReader {
+	return &StreamReader{
 		streamName: streamName,
-		client:     c.copy(),
+		client:     c,
+		opts:       opts,
 		version:    -1,
-		pageSize:   20,
+		pageSize:   DefaultPageSize,
 	}
-	sr.LongPoll(-1)
-	return sr
 }
 
 // NewStreamWriter returns a new *StreamWriter.
-func (c *Client) NewStreamWriter(streamName string) *StreamWriter {
+//
+// Any options provided will be applied to every request made by the writer.
+func (c *Client) NewStreamWriter(streamName string, opts ...RequestOption) *StreamWriter {
 	return &StreamWriter{
 		client:     c,
 		streamName: streamName,
+		opts:       opts,
 	}
 }
 
 // SetBasicAuth sets the credentials for requests.
 //
 // Credentials will be read from the client before each request.
+//
+// SetBasicAuth replaces any Authenticator set on the client.
 func (c *Client) SetBasicAuth(username, password string) {
-	c.credentials = &basicAuthCredentials{
+	c.SetAuthenticator(&BasicAuth{
 		Username: username,
 		Password: password,
-	}
+	})
+}
+
+// SetAuthenticator sets the Authenticator used to authenticate requests.
+//
+// Passing nil removes authentication from subsequent requests.
+func (c *Client) SetAuthenticator(a Authenticator) {
+	c.mu.Lock()
+	c.authenticator = a
+	c.mu.Unlock()
 }
 
 // GetEvent reads a single event from the eventstore.
@@ -168,7 +209,7 @@ func (c *Client) SetBasicAuth(username, password string) {
 // If an error occurs during the http request an *ErrorResponse will be returned
 // as the error. The *ErrorResponse will contain the raw http response and status
 // and a description of the error.
-func (c *Client) GetEvent(url string) (*EventResponse, *Response, error) {
+func (c *Client) GetEvent(url string, opts ...RequestOption) (*EventResponse, *Response, error) {
 
 	r, err := c.NewRequest("GET", url, nil)
 	if err != nil {
@@ -176,26 +217,27 @@ func (c *Client) GetEvent(url string) (*EventResponse, *Response, error) {
 	}
 
 	r.Header.Set("Accept", "application/vnd.eventstore.atom+json")
+	applyOptions(r, opts)
 
-	var b bytes.Buffer
-	resp, err := c.Do(r, &b)
+	resp, err := c.DoStream(r)
 	if err != nil {
 		return nil, resp, err
 	}
+	defer c.closeBody(resp.Body)
 
-	if b.String() == "{}" {
-		return nil, resp, nil
-	}
+	// The eventstore returns an empty object for an event that has no content.
 	var raw json.RawMessage
 	er := &EventAtomResponse{Content: &raw}
-	err = json.NewDecoder(bytes.NewReader(b.Bytes())).Decode(er)
+	err = json.NewDecoder(resp.Body).Decode(er)
 	if err == io.EOF {
 		return nil, resp, nil
 	}
-
 	if err != nil {
 		return nil, resp, err
 	}
+	if len(raw) == 0 {
+		return nil, resp, nil
+	}
 
 	var d json.RawMessage
 	var m json.RawMessage
@@ -212,13 +254,46 @@ func (c *Client) GetEvent(url string) (*EventResponse, *Response, error) {
 	e := EventResponse{}
 	e.Title = er.Title
 	e.ID = er.ID
-	e.Updated = er.Updated
+	e.Updated, _ = er.Updated.Time()
 	e.Summary = er.Summary
 	e.Event = ev
 
 	return &e, resp, nil
 }
 
+// GetEvents reads the events at the urls provided concurrently.
+//
+// At most workers requests will be made at the same time. If workers is less
+// than 1 the events are read one at a time.
+//
+// The results are returned in the same order as the urls. Each result will
+// contain either the event or the error returned when reading that event.
+func (c *Client) GetEvents(urls []string, workers int, opts ...RequestOption) []*EventResult {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]*EventResult, len(urls))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, u := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, u string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			e, _, err := c.GetEvent(u, opts...)
+			results[i] = &EventResult{EventResponse: e, Err: err}
+		}(i, u)
+	}
+	wg.Wait()
+
+	return results
+}
+
 // ReadFeed reads the atom feed for a stream and returns an *atom.Feed.
 //
 // The feed object returned may be nil in case of an error.
@@ -227,7 +302,14 @@ func (c *Client) GetEvent(url string) (*EventResponse, *Response, error) {
 // raw http response and status.
 // If the error occurred during the http request an *ErrorResponse will be returned
 // and this will also contain the raw http request and status and an error message.
-func (c *Client) ReadFeed(url string) (*atom.Feed, *Response, error) {
+//
+// The feed is requested as application/atom+xml unless WithJSONFeed is passed as
+// an option. The feed is decoded according to the content type of the response.
+//
+// If the request was made conditional with If-None-Match and the feed has not
+// changed the feed returned will be nil and the *Response will have the status
+// 304 Not Modified.
+func (c *Client) ReadFeed(url string, opts ...RequestOption) (*atom.Feed, *Response, error) {
 
 	req, err := c.NewRequest("GET", url, nil)
 	if err != nil {
@@ -235,14 +317,25 @@ func (c *Client) ReadFeed(url string) (*atom.Feed, *Response, error) {
 	}
 
 	req.Header.Set("Accept", "application/atom+xml")
+	applyOptions(req, opts)
 
-	var b bytes.Buffer
-	resp, err := c.Do(req, &b)
+	resp, err := c.DoStream(req)
 	if err != nil {
 		return nil, resp, err
 	}
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, resp, nil
+	}
+
+	// The feed is decoded as it is read from the response body.
 	feed := &atom.Feed{}
-	err = xml.NewDecoder(bytes.NewReader(b.Bytes())).Decode(feed)
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		err = json.NewDecoder(resp.Body).Decode(feed)
+	} else {
+		err = xml.NewDecoder(resp.Body).Decode(feed)
+	}
 	if err != nil {
 		return nil, resp, err
 	}
@@ -250,6 +343,123 @@ func (c *Client) ReadFeed(url string) (*atom.Feed, *Response, error) {
 	return feed, resp, nil
 }
 
+// MaxPageSize is the largest number of entries the eventstore will return in
+// a single feed page.
+const MaxPageSize = 4096
+
+// DefaultPageSize is the number of entries requested in each feed page by
+// a StreamReader unless another page size is set.
+const DefaultPageSize = 20
+
+// readEventsWorkers is the number of events read concurrently by ReadEvents.
+const readEventsWorkers = 10
+
+// ReadEvents reads up to count events from a stream beginning at the event
+// number from and returns them in the order in which they were read.
+//
+// Valid directions are "forward" and "backward". When reading forward the events
+// are returned in ascending order of event number, when reading backward the events
+// are returned in descending order. To read backward from the head of the stream
+// pass a negative integer as the from argument.
+//
+// The events are read using as few feed page requests as possible. Fewer than
+// count events will be returned if the start or end of the stream is reached.
+// Pages after the first are read by following the links of the feed so that
+// streams with gaps in their event numbers and streams of link events, whose
+// events have the numbers of the events they point to, are read correctly.
+//
+// If an error occurs reading any feed page or event the error is returned and
+// the events will be nil.
+func (c *Client) ReadEvents(stream string, from, count int, direction string, opts ...RequestOption) ([]*EventResponse, *Response, error) {
+	return c.readEvents(stream, from, count, direction, -1, opts)
+}
+
+// readEvents reads events like ReadEvents. If to is not negative, reading stops
+// at the first entry positioned after the event number to.
+func (c *Client) readEvents(stream string, from, count int, direction string, to int, opts []RequestOption) ([]*EventResponse, *Response, error) {
+	if count <= 0 {
+		return nil, nil, fmt.Errorf("Invalid count %d. Count must be greater than 0.", count)
+	}
+
+	// Each page is sized to read the remaining events in a single request
+	// where the server allows it.
+	pageSize := func(n int) int {
+		if n > MaxPageSize {
+			return MaxPageSize
+		}
+		return n
+	}
+
+	url, err := c.GetFeedPath(stream, direction, from, pageSize(count))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resp *Response
+	events := make([]*EventResponse, 0, count)
+	for {
+		var f *atom.Feed
+		f, resp, err = c.ReadFeed(url, opts...)
+		if err != nil {
+			return nil, resp, err
+		}
+
+		// Feed entries are ordered from the most recent to the oldest event.
+		entries := make([]*atom.Entry, len(f.Entry))
+		copy(entries, f.Entry)
+		if direction == "forward" {
+			for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+		}
+
+		var urls []string
+		done := len(entries) == 0
+		for _, e := range entries {
+			if len(events)+len(urls) == count {
+				break
+			}
+			if pos, ok := entryPosition(stream, e); ok && to >= 0 && pos > to {
+				done = true
+				break
+			}
+			u, err := e.EventURL()
+			if err != nil {
+				return nil, resp, err
+			}
+			urls = append(urls, u)
+		}
+
+		for _, r := range c.GetEvents(urls, readEventsWorkers, opts...) {
+			if r.Err != nil {
+				return nil, resp, r.Err
+			}
+			events = append(events, r.EventResponse)
+		}
+
+		if done || len(events) == count || (direction == "forward" && f.HeadOfStream) {
+			break
+		}
+
+		// GetEventStore uses previous to point to more recent feed pages and
+		// next to point to older feed pages.
+		rel := "previous"
+		if direction == "backward" {
+			rel = "next"
+		}
+		l := f.GetLink(rel)
+		if l == nil {
+			break
+		}
+		url, err = pageSizeURL(l.Href, pageSize(count-len(events)))
+		if err != nil {
+			return nil, resp, err
+		}
+	}
+
+	return events, resp, nil
+}
+
 // GetFeedPath returns the path for a feedpage
 //
 // Valid directions are "forward" and "backward".
@@ -279,19 +489,92 @@ func (c *Client) GetFeedPath(stream, direction string, version int, pageSize int
 	return fmt.Sprintf("/streams/%s/%s/%s/%d", stream, v, dir, ps), nil
 }
 
+// pageSizeURL returns the url of a feed page with the page size replaced by
+// size. The urls of feed pages end with the page size, for example
+// http://127.0.0.1:2113/streams/some-stream/20/forward/20.
+func pageSizeURL(pageURL string, size int) (string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+
+	p := u.EscapedPath()
+	i := strings.LastIndex(p, "/")
+	if _, err := strconv.Atoi(p[i+1:]); i < 0 || err != nil {
+		return "", fmt.Errorf("Invalid feed page url %s.", pageURL)
+	}
+	p = p[:i+1] + strconv.Itoa(size)
+	if u.Path, err = url.PathUnescape(p); err != nil {
+		return "", err
+	}
+	u.RawPath = p
+	return u.String(), nil
+}
+
+// pageVersion returns the version a feed page starts from, which is the
+// segment of the url before the direction, for example 20 in
+// http://127.0.0.1:2113/streams/some-stream/20/forward/20.
+func pageVersion(pageURL string) (int, bool) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return 0, false
+	}
+	parts := strings.Split(u.EscapedPath(), "/")
+	if len(parts) < 3 {
+		return 0, false
+	}
+	v, err := strconv.Atoi(parts[len(parts)-3])
+	return v, err == nil
+}
+
+// entryPosition returns the event number of a feed entry in the stream.
+//
+// For the entries of a stream of link events, such as a category stream, the
+// position is the number of the link event rather than the number of the event
+// it points to. ok is false if the position cannot be determined from the entry,
+// which is the case for entries of links resolved in feeds that are not read
+// with WithJSONFeed.
+func entryPosition(stream string, e *atom.Entry) (int, bool) {
+	if e.PositionStreamID != "" {
+		return e.PositionEventNumber, e.PositionStreamID == stream
+	}
+
+	href, err := e.EventURL()
+	if err != nil {
+		return 0, false
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return 0, false
+	}
+	parts := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	if len(parts) < 3 || parts[len(parts)-3] != "streams" {
+		return 0, false
+	}
+	name, err := url.PathUnescape(parts[len(parts)-2])
+	if err != nil || name != stream {
+		return 0, false
+	}
+	n, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
 // GetMetadataURL gets the url for the stream metadata.
 // according to the documentation the metadata url should be acquired through
 // a query to the stream feed as the authors of GetEventStore reserve the right
 // to change the url.
 // http://docs.geteventstore.com/http-api/latest/stream-metadata/
-func (c *Client) GetMetadataURL(stream string) (string, *Response, error) {
+func (c *Client) GetMetadataURL(stream string, opts ...RequestOption) (string, *Response, error) {
 
 	url, err := c.GetFeedPath(stream, "forward", 0, 1)
 	if err != nil {
 		return "", nil, err
 	}
 
-	f, resp, err := c.ReadFeed(url)
+	f, resp, err := c.ReadFeed(url, opts...)
 	if err != nil {
 		return "", resp, err
 	}
@@ -307,12 +590,16 @@ func (c *Client) GetMetadataURL(stream string) (string, *Response, error) {
 //
 // Any headers that are set on the client will be included in requests to the eventstore.
 func (c *Client) SetHeader(key, value string) {
+	c.mu.Lock()
 	c.headers[key] = value
+	c.mu.Unlock()
 }
 
 // DeleteHeader deletes a header from the collection of headers.
 func (c *Client) DeleteHeader(key string) {
+	c.mu.Lock()
 	delete(c.headers, key)
+	c.mu.Unlock()
 }
 
 // DeleteStream will delete a stream
@@ -326,7 +613,7 @@ func (c *Client) DeleteHeader(key string) {
 // be recreated.
 //
 // http://docs.geteventstore.com/http-api/3.8.0/deleting-a-stream/
-func (c *Client) DeleteStream(streamName string, hardDelete bool) (*Response, error) {
+func (c *Client) DeleteStream(streamName string, hardDelete bool, opts ...RequestOption) (*Response, error) {
 
 	url := fmt.Sprintf("/streams/%s", streamName)
 
@@ -338,6 +625,7 @@ func (c *Client) DeleteStream(streamName string, hardDelete bool) (*Response, er
 	if hardDelete {
 		req.Header.Set("ES-HardDelete", "true")
 	}
+	applyOptions(req, opts)
 
 	resp, err := c.Do(req, nil)
 	if err != nil {
@@ -350,7 +638,11 @@ func (c *Client) DeleteStream(streamName string, hardDelete bool) (*Response, er
 
 // NewRequest creates a new *http.Request that can be used to execute requests to the
 // server using the client.
-func (c *Client) NewRequest(method, urlString string, body interface{}) (*http.Request, error) {
+//
+// The request is created with the headers set on the client and authenticated
+// with the client's Authenticator.
+// Any options provided are applied last and take precedence.
+func (c *Client) NewRequest(method, urlString string, body interface{}, opts ...RequestOption) (*http.Request, error) {
 
 	url, err := url.Parse(urlString)
 	if err != nil {
@@ -375,24 +667,74 @@ func (c *Client) NewRequest(method, urlString string, body interface{}) (*http.R
 		return nil, err
 	}
 
-	if c.credentials != nil {
-		req.SetBasicAuth(c.credentials.Username, c.credentials.Password)
-	}
-
+	c.mu.RLock()
+	authenticator := c.authenticator
 	for k, v := range c.headers {
 		req.Header.Set(k, v)
 	}
+	c.mu.RUnlock()
+
+	// The authenticator is called outside of the lock as it may need to
+	// make a request of its own, for example to refresh a token.
+	if authenticator != nil {
+		if err := authenticator.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+
+	applyOptions(req, opts)
 
 	return req, nil
 }
 
+// applyOptions applies each of the request options to req in order.
+func applyOptions(req *http.Request, opts []RequestOption) {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(req)
+		}
+	}
+}
+
 // Do executes requests to the server.
 //
 // The response body is copied into v if v is not nil.
 // Returns a *Response that wraps the http.Response returned from the server.
 // The response body is available in the *Response in case the consumer wishes
 // to process it in some way rather than read if from the argument v
+//
+// If a cache has been set with SetCache, GET requests for cacheable resources
+// are served from the cache when possible. Responses served from the cache have
+// the header X-From-Cache set.
 func (c *Client) Do(req *http.Request, v io.Writer) (*Response, error) {
+	response, err := c.DoStream(req)
+	if err != nil {
+		return response, err
+	}
+	defer c.closeBody(response.Body)
+
+	// When handling post requests v will be nil
+	if v != nil {
+		if _, err := io.Copy(v, response.Body); err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
+// DoStream executes requests to the server like Do but returns the response
+// with its body open so that it can be decoded as it is read.
+//
+// If the error returned is nil the caller must close the body of the response.
+// If the client has a maximum response size set with SetMaxResponseSize, reading
+// more than that many bytes from the body returns an *ErrResponseTooLarge.
+func (c *Client) DoStream(req *http.Request) (*Response, error) {
+
+	// The headers set for compression and caching are set on a copy of the
+	// request so that the caller's request is not changed.
+	orig := req
+	req = req.Clone(req.Context())
 
 	// keep is a copy of the request body that will be returned
 	// with the response for diagnostic purposes.
@@ -402,10 +744,30 @@ func (c *Client) Do(req *http.Request, v io.Writer) (*Response, error) {
 	if req.Body != nil {
 		if buf, err := ioutil.ReadAll(req.Body); err == nil {
 			keep = ioutil.NopCloser(bytes.NewReader(buf))
-			send = ioutil.NopCloser(bytes.NewReader(buf))
+			send = ioutil.NopCloser(bytes.NewReader(c.compressRequest(req, buf)))
 			req.Body = send
 		}
 	}
+	c.acceptCompression(req)
+
+	// Responses stored in the cache are served without a request to the
+	// server until they expire, after which they are revalidated using their
+	// ETag if they have one.
+	cache, key := c.cacheFor(req)
+	var stale *CachedResponse
+	if cache != nil {
+		if cr, ok := cache.Get(key); ok {
+			if time.Now().Before(cr.Expires) {
+				return newResponse(cachedHTTPResponse(req, cr)), nil
+			}
+			if etag := cr.Header.Get("ETag"); etag != "" && req.Header.Get("If-None-Match") == "" {
+				req.Header.Set("If-None-Match", etag)
+				stale = cr
+			} else {
+				cache.Delete(key)
+			}
+		}
+	}
 
 	// An error is returned if caused by client policy (such as CheckRedirect),
 	// or if there was an HTTP protocol error. A non-2xx response doesn't cause
@@ -415,30 +777,61 @@ func (c *Client) Do(req *http.Request, v io.Writer) (*Response, error) {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	if err := c.decompressResponse(resp); err != nil {
+		resp.Body.Close()
+		return newResponse(resp), err
+	}
+	c.limitResponse(resp)
+
+	if stale != nil {
+		if resp.StatusCode == http.StatusNotModified {
+			c.closeBody(resp.Body)
+			req.Header.Del("If-None-Match")
+			cr := *stale
+			if expires, ok := cacheExpiry(&http.Response{StatusCode: http.StatusOK, Header: resp.Header}); ok {
+				cr.Expires = expires
+				cache.Set(key, &cr)
+			}
+			return newResponse(cachedHTTPResponse(req, &cr)), nil
+		}
+		cache.Delete(key)
+	}
 
 	// Create a *Response to wrap the http.Response
 	response := newResponse(resp)
 
 	// After the request has been made the req.Body will be unreadable.
-	// assign keep to the request body so that it can be returned in the
-	// response for diagnostic purposes.
+	// assign keep to the body of the original request so that it can be
+	// returned in the response for diagnostic purposes.
 	if keep != nil {
-		req.Body = keep
+		orig.Body = keep
 	}
 
 	// If the request returned an error status checkResponse will return an
 	// *errorResponse containing the original request, status code and status message
-	err = getError(resp, req)
+	err = getError(resp, orig)
 	if err != nil {
+		resp.Body.Close()
 		// even though there was an error, we still return the response
 		// in case the caller wants to inspect it further
 		return response, err
 	}
 
-	// When handling post requests v will be nil
-	if v != nil {
-		io.Copy(v, resp.Body)
+	if cache != nil {
+		if expires, ok := cacheExpiry(resp); ok {
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return response, err
+			}
+			cache.Set(key, &CachedResponse{
+				StatusCode: resp.StatusCode,
+				Header:     resp.Header,
+				Body:       body,
+				Expires:    expires,
+			})
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
 	}
 
 	return response, nil
@@ -447,18 +840,24 @@ func (c *Client) Do(req *http.Request, v io.Writer) (*Response, error) {
 // getError inspects the HTTP response and constructs an appropriate error if
 // the response was an error.
 func getError(r *http.Response, req *http.Request) error {
-	if c := r.StatusCode; 200 <= c && c <= 299 {
+	if c := r.StatusCode; 200 <= c && c <= 299 || c == http.StatusNotModified {
 		return nil
 	}
 
-	errorResponse := &ErrorResponse{Response: r}
-	data, err := ioutil.ReadAll(r.Body)
-	if err == nil && data != nil {
-		json.Unmarshal(data, errorResponse)
+	// The body is kept so that it can be read again from the response.
+	data, _ := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	errorResponse := &ErrorResponse{
+		Response:   r,
+		Request:    req,
+		Status:     r.Status,
+		StatusCode: r.StatusCode,
+		Reason:     strings.TrimSpace(strings.TrimPrefix(r.Status, strconv.Itoa(r.StatusCode))),
+		Message:    string(data),
 	}
-	errorResponse.Status = r.Status
-	errorResponse.StatusCode = r.StatusCode
-	errorResponse.Request = req
+	errorResponse.StreamName, errorResponse.Operation = describeRequest(req)
 
 	switch r.StatusCode {
 	case http.StatusBadRequest:
@@ -476,6 +875,42 @@ func getError(r *http.Response, req *http.Request) error {
 	}
 }
 
+// describeRequest returns the name of the stream and the operation of a request
+// to a stream. Both are empty if the request is not made to a stream.
+func describeRequest(req *http.Request) (streamName, operation string) {
+	parts := strings.Split(strings.TrimPrefix(req.URL.EscapedPath(), "/"), "/")
+	if len(parts) < 2 || parts[0] != "streams" {
+		return "", ""
+	}
+	streamName, err := url.PathUnescape(parts[1])
+	if err != nil {
+		streamName = parts[1]
+	}
+
+	// Metadata is stored in a stream named after the stream prefixed with $$.
+	metadata := len(parts) > 2 && parts[2] == "metadata"
+	if strings.HasPrefix(streamName, "$$") {
+		streamName = strings.TrimPrefix(streamName, "$$")
+		metadata = true
+	}
+
+	switch {
+	case metadata && req.Method == http.MethodGet:
+		operation = "read metadata"
+	case metadata:
+		operation = "write metadata"
+	case req.Method == http.MethodGet:
+		operation = "read"
+	case req.Method == http.MethodPost:
+		operation = "append"
+	case req.Method == http.MethodDelete:
+		operation = "delete"
+	default:
+		operation = strings.ToLower(req.Method)
+	}
+	return streamName, operation
+}
+
 // newResponse creates a new Response for the provided http.Response.
 func newResponse(r *http.Response) *Response {
 	response := &Response{Response: r}
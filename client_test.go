@@ -7,14 +7,19 @@ package goes_test
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/jetbasrawi/go.geteventstore"
 	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	"github.com/jetbasrawi/go.geteventstore/atom"
 	. "gopkg.in/check.v1"
 )
 
@@ -48,6 +53,90 @@ func (s *ClientAPISuite) TestReadStream(c *C) {
 	c.Assert(resp.StatusCode, DeepEquals, http.StatusOK)
 }
 
+// jsonFeed is a feed page in the application/vnd.eventstore.atom+json format
+// read with embed=body from a category stream with links resolved.
+const jsonFeed = `{
+  "title": "Event stream '$ce-order'",
+  "id": "http://localhost:2113/streams/%24ce-order",
+  "updated": "2016-11-22T09:55:22.2596297Z",
+  "streamId": "$ce-order",
+  "author": {"name": "EventStore"},
+  "headOfStream": true,
+  "selfUrl": "http://localhost:2113/streams/%24ce-order",
+  "eTag": "1;248368668",
+  "links": [
+    {"uri": "http://localhost:2113/streams/%24ce-order", "relation": "self"},
+    {"uri": "http://localhost:2113/streams/%24ce-order/2/forward/20", "relation": "previous"}
+  ],
+  "entries": [
+    {
+      "eventId": "fbf4a1a1-b4a3-4dfe-a01f-ec52c34e16e4",
+      "eventType": "OrderPlaced",
+      "eventNumber": 3,
+      "data": "{\"total\":10}",
+      "metaData": "{\"user\":\"a\"}",
+      "streamId": "order-1",
+      "isJson": true,
+      "isMetaData": true,
+      "isLinkMetaData": false,
+      "positionEventNumber": 1,
+      "positionStreamId": "$ce-order",
+      "title": "3@order-1",
+      "id": "http://localhost:2113/streams/order-1/3",
+      "updated": "2016-11-22T09:55:22.2596297Z",
+      "author": {"name": "EventStore"},
+      "summary": "OrderPlaced",
+      "links": [
+        {"uri": "http://localhost:2113/streams/order-1/3", "relation": "edit"},
+        {"uri": "http://localhost:2113/streams/order-1/3", "relation": "alternate"}
+      ]
+    }
+  ]
+}`
+
+func (s *ClientAPISuite) TestReadFeedJSON(c *C) {
+	path := "/streams/$ce-order/head/backward/20"
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("Accept"), Equals, "application/vnd.eventstore.atom+json")
+		c.Assert(r.URL.Query().Get("embed"), Equals, "body")
+		w.Header().Set("Content-Type", "application/vnd.eventstore.atom+json; charset=utf-8")
+		fmt.Fprint(w, jsonFeed)
+	})
+
+	feed, _, err := client.ReadFeed(path, goes.WithJSONFeed(), goes.WithEmbed("body"))
+	c.Assert(err, IsNil)
+	c.Assert(feed.StreamID, Equals, "$ce-order")
+	c.Assert(feed.HeadOfStream, Equals, true)
+	c.Assert(feed.ETag, Equals, "1;248368668")
+	c.Assert(feed.GetLink("previous").Href, Equals, "http://localhost:2113/streams/%24ce-order/2/forward/20")
+	c.Assert(feed.Entry, HasLen, 1)
+
+	e := feed.Entry[0]
+	c.Assert(e.Title, Equals, "3@order-1")
+	c.Assert(e.Summary.Body, Equals, "OrderPlaced")
+	c.Assert(e.EventType, Equals, "OrderPlaced")
+	c.Assert(e.EventNumber, Equals, 3)
+	c.Assert(e.StreamID, Equals, "order-1")
+	c.Assert(e.PositionEventNumber, Equals, 1)
+	c.Assert(e.PositionStreamID, Equals, "$ce-order")
+	c.Assert(e.IsJSON, Equals, true)
+
+	data, err := e.EventData()
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"total":10}`)
+	meta, err := e.EventMetaData()
+	c.Assert(err, IsNil)
+	c.Assert(string(meta), Equals, `{"user":"a"}`)
+
+	updated, err := e.UpdatedTime()
+	c.Assert(err, IsNil)
+	c.Assert(updated.Nanosecond(), Equals, 259629700)
+
+	urls, err := feed.GetEventURLs()
+	c.Assert(err, IsNil)
+	c.Assert(urls, DeepEquals, []string{"http://localhost:2113/streams/order-1/3"})
+}
+
 func (s *ClientAPISuite) TestConstructNewClientInvalidURL(c *C) {
 	invalidURL := ":"
 	_, err := goes.NewClient(nil, invalidURL)
@@ -162,6 +251,118 @@ func (s *ClientAPISuite) TestErrorResponseContainsStatusCodeAndMessage(c *C) {
 	}
 }
 
+func (s *ClientAPISuite) TestErrorResponseContainsServerDetails(c *C) {
+	mux.HandleFunc("/streams/some-stream/head/backward/20", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Stream not found", http.StatusNotFound)
+	})
+
+	_, _, err := client.ReadFeed("/streams/some-stream/head/backward/20")
+
+	e, ok := err.(*goes.ErrNotFound)
+	c.Assert(ok, Equals, true)
+	c.Assert(e.ErrorResponse.Reason, Equals, "Not Found")
+	c.Assert(e.ErrorResponse.Message, Equals, "Stream not found\n")
+	c.Assert(e.ErrorResponse.StreamName, Equals, "some-stream")
+	c.Assert(e.ErrorResponse.Operation, Equals, "read")
+	c.Assert(err.Error(), Equals, `The stream does not exist. (read "some-stream": 404 Not Found: Stream not found)`)
+
+	// The body can still be read from the response.
+	body, err := ioutil.ReadAll(e.ErrorResponse.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(body), Equals, "Stream not found\n")
+}
+
+func (s *ClientAPISuite) TestErrorResponseOperation(c *C) {
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	tests := []struct {
+		method, path, stream, operation string
+	}{
+		{http.MethodPost, "/streams/some-stream", "some-stream", "append"},
+		{http.MethodDelete, "/streams/some%20stream", "some stream", "delete"},
+		{http.MethodGet, "/streams/some-stream/metadata", "some-stream", "read metadata"},
+		{http.MethodPost, "/streams/$$some-stream", "some-stream", "write metadata"},
+		{http.MethodGet, "/users/admin", "", ""},
+	}
+	for _, tt := range tests {
+		req, _ := client.NewRequest(tt.method, tt.path, nil)
+		_, err := client.Do(req, nil)
+		e, ok := err.(*goes.ErrBadRequest)
+		c.Assert(ok, Equals, true)
+		c.Assert(e.ErrorResponse.StreamName, Equals, tt.stream)
+		c.Assert(e.ErrorResponse.Operation, Equals, tt.operation)
+	}
+}
+
+func (s *ClientAPISuite) TestErrorsCanBeMatchedWithErrorsIsAndAs(c *C) {
+	mux.HandleFunc("/streams/some-stream", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req, _ := client.NewRequest(http.MethodGet, "/streams/some-stream", nil)
+	_, err := client.Do(req, nil)
+	wrapped := fmt.Errorf("reading: %w", err)
+
+	c.Assert(errors.Is(wrapped, goes.ErrStreamNotFound), Equals, true)
+	c.Assert(errors.Is(wrapped, goes.ErrStreamDeleted), Equals, false)
+
+	var nf *goes.ErrNotFound
+	c.Assert(errors.As(wrapped, &nf), Equals, true)
+	c.Assert(nf, Equals, err)
+	var d *goes.ErrDeleted
+	c.Assert(errors.As(wrapped, &d), Equals, false)
+
+	var er *goes.ErrorResponse
+	c.Assert(errors.As(wrapped, &er), Equals, true)
+	c.Assert(er.StatusCode, Equals, http.StatusNotFound)
+}
+
+// Tests that each error type matches its sentinel and no other, whether the
+// error is a value or a pointer.
+func (s *ClientAPISuite) TestErrorsMatchTheirSentinels(c *C) {
+	tests := []struct {
+		err      error
+		sentinel error
+	}{
+		{goes.ErrNoMoreEvents{}, goes.ErrEndOfStream},
+		{goes.ErrNotFound{}, goes.ErrStreamNotFound},
+		{goes.ErrDeleted{}, goes.ErrStreamDeleted},
+		{goes.ErrUnauthorized{}, goes.ErrNotAuthorized},
+		{goes.ErrTemporarilyUnavailable{}, goes.ErrServerNotReady},
+		{goes.ErrUnexpected{}, goes.ErrUnexpectedResponse},
+		{goes.ErrBadRequest{}, goes.ErrInvalidRequest},
+		{goes.ErrConflict{}, goes.ErrResourceConflict},
+		{goes.ErrConcurrencyViolation{}, goes.ErrWrongExpectedVersion},
+		{goes.ErrUnresolvedLink{}, goes.ErrLinkNotResolved},
+		{goes.ErrFeedLoop{}, goes.ErrFeedPageLoop},
+		{goes.ErrResponseTooLarge{}, goes.ErrResponseLimitExceeded},
+	}
+	for i, tt := range tests {
+		c.Assert(errors.Is(tt.err, tt.sentinel), Equals, true, Commentf("%T", tt.err))
+		c.Assert(errors.Is(fmt.Errorf("%w", tt.err), tt.sentinel), Equals, true, Commentf("%T", tt.err))
+		other := tests[(i+1)%len(tests)].sentinel
+		c.Assert(errors.Is(tt.err, other), Equals, false, Commentf("%T", tt.err))
+	}
+
+	// The errors are returned as pointers.
+	c.Assert(errors.Is(&goes.ErrNotFound{}, goes.ErrStreamNotFound), Equals, true)
+	c.Assert(errors.Is(&goes.ErrNoMoreEvents{}, goes.ErrEndOfStream), Equals, true)
+}
+
+// Tests that a conflict returned for a request that is not made by the user
+// service is returned as an ErrUnexpected.
+func (s *ClientAPISuite) TestStreamConflictReturnsErrUnexpected(c *C) {
+	mux.HandleFunc("/streams/some-stream", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+
+	req, _ := client.NewRequest(http.MethodPost, "/streams/some-stream", nil)
+	_, err := client.Do(req, nil)
+	c.Assert(err, FitsTypeOf, &goes.ErrUnexpected{})
+}
+
 func (s *ClientAPISuite) TestGetEvent(c *C) {
 	stream := "GetEventStream"
 	es := mock.CreateTestEvents(1, stream, server.URL, "SomeEventType")
@@ -182,7 +383,7 @@ func (s *ClientAPISuite) TestGetEvent(c *C) {
 
 	got, _, err := client.GetEvent("/streams/some-stream/299")
 	c.Assert(err, IsNil)
-	c.Assert(got.PrettyPrint(), Equals, want.PrettyPrint())
+	assertEventResponse(c, got, want)
 }
 
 func (s *ClientAPISuite) TestGetEventURLs(c *C) {
@@ -198,6 +399,41 @@ func (s *ClientAPISuite) TestGetEventURLs(c *C) {
 	c.Assert(got, DeepEquals, want)
 }
 
+// Tests that event urls are found by link relation regardless of the order
+// or number of the links of the entries.
+func (s *ClientAPISuite) TestGetEventURLsFindsLinksByRelation(c *C) {
+	es := mock.CreateTestEvents(3, "some-stream", "http://localhost:2113", "EventTypeX")
+	f, _ := mock.CreateTestFeed(es, "http://localhost:2113/streams/some-stream/head/backward/3")
+
+	f.Entry[0].Link = []atom.Link{
+		{Rel: "alternate", Href: "http://localhost:2113/streams/some-stream/2/"},
+		{Rel: "edit", Href: "http://localhost:2113/other"},
+	}
+	f.Entry[1].Link = []atom.Link{{Rel: "edit", Href: "http://localhost:2113/streams/some-stream/1"}}
+
+	got, err := f.GetEventURLs()
+	c.Assert(err, IsNil)
+	want := []string{
+		"http://localhost:2113/streams/some-stream/2",
+		"http://localhost:2113/streams/some-stream/1",
+		"http://localhost:2113/streams/some-stream/0",
+	}
+	c.Assert(got, DeepEquals, want)
+	c.Assert(f.Entry[0].GetLink("edit").Href, Equals, "http://localhost:2113/other")
+	c.Assert(f.Entry[1].GetLink("alternate"), IsNil)
+}
+
+func (s *ClientAPISuite) TestGetEventURLsMalformedEntry(c *C) {
+	es := mock.CreateTestEvents(2, "some-stream", "http://localhost:2113", "EventTypeX")
+	f, _ := mock.CreateTestFeed(es, "http://localhost:2113/streams/some-stream/head/backward/2")
+	f.Entry[1].Link = []atom.Link{{Rel: "self", Href: "http://localhost:2113/streams/some-stream/0"}}
+
+	_, err := f.GetEventURLs()
+	e, ok := err.(*atom.ErrMalformedEntry)
+	c.Assert(ok, Equals, true)
+	c.Assert(e.Title, Equals, "0@some-stream")
+}
+
 func (s *ClientAPISuite) TestSoftDeleteStream(c *C) {
 	streamName := "foostream"
 	mux.HandleFunc("/streams/foostream", func(w http.ResponseWriter, r *http.Request) {
@@ -310,3 +546,174 @@ func (s *ClientAPISuite) TestGetFeedPathInvalidDirectionAndVersion(c *C) {
 	c.Assert(got, DeepEquals, want)
 	c.Assert(err, DeepEquals, fmt.Errorf("Invalid Direction (%s) and version (head) combination.\n", direction))
 }
+
+// Tests that headers can be set and deleted while other goroutines are building
+// requests and creating readers and writers from the same client.
+// Run with -race to detect unsynchronised access to the client configuration.
+func (s *ClientAPISuite) TestClientHeadersAreSafeForConcurrentUse(c *C) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				key := "X-Header-" + strconv.Itoa(j%5)
+				client.SetHeader(key, strconv.Itoa(i))
+				client.DeleteHeader(key)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, err := client.NewRequest(http.MethodGet, "/streams/foo", nil)
+				c.Check(err, IsNil)
+				c.Check(client.NewStreamReader("foo"), NotNil)
+				c.Check(client.NewStreamWriter("foo"), NotNil)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Tests that credentials can be rotated while requests are being made and that
+// each request carries a consistent username and password pair.
+func (s *ClientAPISuite) TestCredentialsCanBeRotatedWhileRequestsAreInFlight(c *C) {
+	valid := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		u, p := "user"+strconv.Itoa(i), "pass"+strconv.Itoa(i)
+		valid["Basic "+base64.StdEncoding.EncodeToString([]byte(u+":"+p))] = true
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		c.Check(valid[r.Header.Get("Authorization")], Equals, true)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	client.SetBasicAuth("user0", "pass0")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 100; j++ {
+			n := strconv.Itoa(j % 5)
+			client.SetBasicAuth("user"+n, "pass"+n)
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				req, err := client.NewRequest(http.MethodGet, "/streams/foo", nil)
+				c.Check(err, IsNil)
+				_, err = client.Do(req, nil)
+				c.Check(err, IsNil)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *ClientAPISuite) TestGetEventsReturnsResultsInOrder(c *C) {
+	stream := "some-stream"
+	es := mock.CreateTestEvents(20, stream, server.URL, "EventTypeX")
+	setupSimulator(es, nil)
+
+	urls := []string{}
+	for _, e := range es {
+		urls = append(urls, e.Links[0].URI)
+	}
+	urls = append(urls, fmt.Sprintf("%s/streams/%s/99", server.URL, stream))
+
+	results := client.GetEvents(urls, 4)
+	c.Assert(results, HasLen, len(urls))
+	for i, e := range es {
+		c.Assert(results[i].Err, IsNil)
+		c.Assert(results[i].EventResponse.Event.EventID, Equals, e.EventID)
+	}
+	c.Assert(results[20].EventResponse, IsNil)
+	c.Assert(reflect.TypeOf(results[20].Err).Elem().Name(), Equals, "ErrNotFound")
+}
+
+func (s *ClientAPISuite) TestReadEventsForward(c *C) {
+	stream := "some-stream"
+	es := mock.CreateTestEvents(30, stream, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	sim, _ := mock.NewAtomFeedSimulator(es, u, nil, -1)
+
+	pages := []string{}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "application/atom+xml" {
+			pages = append(pages, r.URL.Path)
+		}
+		sim.ServeHTTP(w, r)
+	})
+
+	got, _, err := client.ReadEvents(stream, 5, 10, "forward")
+	c.Assert(err, IsNil)
+	c.Assert(got, HasLen, 10)
+	for i, e := range got {
+		c.Assert(e.Event.EventID, Equals, es[i+5].EventID)
+	}
+	c.Assert(pages, DeepEquals, []string{"/streams/some-stream/5/forward/10"})
+}
+
+func (s *ClientAPISuite) TestReadEventsForwardPastEndOfStream(c *C) {
+	stream := "some-stream"
+	es := mock.CreateTestEvents(30, stream, server.URL, "EventTypeX")
+	setupSimulator(es, nil)
+
+	got, _, err := client.ReadEvents(stream, 25, 10, "forward")
+	c.Assert(err, IsNil)
+	c.Assert(got, HasLen, 5)
+	c.Assert(got[0].Event.EventID, Equals, es[25].EventID)
+	c.Assert(got[4].Event.EventID, Equals, es[29].EventID)
+}
+
+func (s *ClientAPISuite) TestReadEventsBackward(c *C) {
+	stream := "some-stream"
+	es := mock.CreateTestEvents(30, stream, server.URL, "EventTypeX")
+	setupSimulator(es, nil)
+
+	got, _, err := client.ReadEvents(stream, 10, 20, "backward")
+	c.Assert(err, IsNil)
+	c.Assert(got, HasLen, 11)
+	for i, e := range got {
+		c.Assert(e.Event.EventID, Equals, es[10-i].EventID)
+	}
+}
+
+// eventNumbers returns the event numbers of the events.
+func eventNumbers(events []*goes.EventResponse) []int {
+	var numbers []int
+	for _, e := range events {
+		numbers = append(numbers, e.Event.EventNumber)
+	}
+	return numbers
+}
+
+// Tests that the pages of a stream with gaps in its event numbers are read by
+// following the links of the feed when the server returns smaller pages than
+// those requested.
+func (s *ClientAPISuite) TestReadEventsWithGaps(c *C) {
+	mux.Handle("/", capPageSize(gapSimulator("some-stream", []int{0, 1, 2, 5, 6, 9, 10, 11, 15}), 3))
+
+	got, _, err := client.ReadEvents("some-stream", 2, 5, "forward")
+	c.Assert(err, IsNil)
+	c.Assert(eventNumbers(got), DeepEquals, []int{2, 5, 6, 9, 10})
+
+	got, _, err = client.ReadEvents("some-stream", 3, 10, "forward")
+	c.Assert(err, IsNil)
+	c.Assert(eventNumbers(got), DeepEquals, []int{5, 6, 9, 10, 11, 15})
+
+	got, _, err = client.ReadEvents("some-stream", -1, 5, "backward")
+	c.Assert(err, IsNil)
+	c.Assert(eventNumbers(got), DeepEquals, []int{15, 11, 10, 9, 6})
+}
+
+func (s *ClientAPISuite) TestReadEventsInvalidCount(c *C) {
+	_, _, err := client.ReadEvents("some-stream", 0, 0, "forward")
+	c.Assert(err, NotNil)
+}
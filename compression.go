@@ -0,0 +1,164 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// TransferStats holds the number of bytes transferred by a Client.
+//
+// The Sent and Received counts are the bytes of request and response bodies
+// as transferred and the Uncompressed counts are the bytes of the same bodies
+// before compression or after decompression. When bodies are not compressed
+// the counts are the same.
+type TransferStats struct {
+	BytesSent                 int64
+	BytesSentUncompressed     int64
+	BytesReceived             int64
+	BytesReceivedUncompressed int64
+}
+
+// SetCompression sets whether responses are requested with gzip or deflate
+// compression. Compressed responses are decompressed transparently.
+// Compression is enabled by default.
+func (c *Client) SetCompression(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disableCompression = !enabled
+}
+
+// SetRequestCompression causes request bodies of minSize bytes or more to be
+// compressed with gzip. This reduces the size of large appends, however, the
+// server or a proxy in front of it must support gzip encoded requests.
+//
+// Request compression is disabled by default. A minSize of 0 or less disables
+// request compression.
+func (c *Client) SetRequestCompression(minSize int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requestCompressionSize = minSize
+}
+
+// TransferStats returns the number of bytes transferred by the client.
+func (c *Client) TransferStats() TransferStats {
+	return TransferStats{
+		BytesSent:                 atomic.LoadInt64(&c.stats.BytesSent),
+		BytesSentUncompressed:     atomic.LoadInt64(&c.stats.BytesSentUncompressed),
+		BytesReceived:             atomic.LoadInt64(&c.stats.BytesReceived),
+		BytesReceivedUncompressed: atomic.LoadInt64(&c.stats.BytesReceivedUncompressed),
+	}
+}
+
+// compressRequest returns the body to send for a request with the body
+// provided, compressing it with gzip if the body is large enough.
+func (c *Client) compressRequest(req *http.Request, body []byte) []byte {
+	c.mu.RLock()
+	minSize := c.requestCompressionSize
+	c.mu.RUnlock()
+
+	atomic.AddInt64(&c.stats.BytesSentUncompressed, int64(len(body)))
+	send := body
+	if minSize > 0 && len(body) >= minSize && req.Header.Get("Content-Encoding") == "" {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err == nil && zw.Close() == nil {
+			send = buf.Bytes()
+			req.Header.Set("Content-Encoding", "gzip")
+			req.ContentLength = int64(len(send))
+		}
+	}
+	atomic.AddInt64(&c.stats.BytesSent, int64(len(send)))
+	return send
+}
+
+// acceptCompression sets Accept-Encoding on the request if compression is
+// enabled and the request does not already set it.
+func (c *Client) acceptCompression(req *http.Request) {
+	c.mu.RLock()
+	disabled := c.disableCompression
+	c.mu.RUnlock()
+
+	if !disabled && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+}
+
+// decompressResponse replaces the body of the response with a reader that
+// decompresses it according to its Content-Encoding and counts the bytes
+// received.
+func (c *Client) decompressResponse(resp *http.Response) error {
+	body := io.ReadCloser(&countingReader{r: resp.Body, n: &c.stats.BytesReceived})
+
+	var r io.Reader
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			body.Close()
+			return err
+		}
+		r = zr
+	case "deflate":
+		// Deflate encoded bodies should be zlib streams, however, some
+		// servers send raw deflate data.
+		br := bufio.NewReader(body)
+		if header, err := br.Peek(2); err == nil && isZlibHeader(header) {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				body.Close()
+				return err
+			}
+			r = zr
+		} else {
+			r = flate.NewReader(br)
+		}
+	default:
+		resp.Body = &countingReader{r: body, n: &c.stats.BytesReceivedUncompressed}
+		return nil
+	}
+
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Body = &countingReader{
+		r: struct {
+			io.Reader
+			io.Closer
+		}{r, body},
+		n: &c.stats.BytesReceivedUncompressed,
+	}
+	return nil
+}
+
+// isZlibHeader reports whether b is the header of a zlib stream.
+func isZlibHeader(b []byte) bool {
+	return b[0]&0x0f == 8 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
+
+// countingReader counts the bytes read from a reader.
+type countingReader struct {
+	r io.ReadCloser
+	n *int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(c.n, int64(n))
+	return n, err
+}
+
+func (c *countingReader) Close() error {
+	return c.r.Close()
+}
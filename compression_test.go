@@ -0,0 +1,171 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&CompressionSuite{})
+
+type CompressionSuite struct{}
+
+func (s *CompressionSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *CompressionSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+// compress returns body compressed with the encoding provided.
+func compress(c *C, encoding, body string) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		var err error
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		c.Assert(err, IsNil)
+	}
+	_, err := io.WriteString(w, body)
+	c.Assert(err, IsNil)
+	c.Assert(w.Close(), IsNil)
+	return buf.Bytes()
+}
+
+func (s *CompressionSuite) TestGzipResponsesAreDecompressed(c *C) {
+	path := "/streams/SomeStream/head/backward/20"
+	es := mock.CreateTestEvents(20, "SomeStream", server.URL, "FooEvent")
+	f, _ := mock.CreateTestFeed(es, server.URL+path)
+	body := compress(c, "gzip", f.PrettyPrint())
+
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("Accept-Encoding"), Equals, "gzip, deflate")
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(body)
+	})
+
+	got, _, err := client.ReadFeed(path)
+	c.Assert(err, IsNil)
+	c.Assert(got.PrettyPrint(), Equals, f.PrettyPrint())
+
+	stats := client.TransferStats()
+	c.Assert(stats.BytesReceived, Equals, int64(len(body)))
+	c.Assert(stats.BytesReceivedUncompressed, Equals, int64(len(f.PrettyPrint())))
+}
+
+// Tests that deflate encoded responses are decompressed whether they are
+// zlib streams or raw deflate data.
+func (s *CompressionSuite) TestDeflateResponsesAreDecompressed(c *C) {
+	es := mock.CreateTestEvents(1, "SomeStream", server.URL, "FooEvent")
+	er, _ := mock.CreateTestEventAtomResponse(es[0], nil)
+	want := mock.CreateTestEventResponse(es[0], nil)
+
+	for _, encoding := range []string{"zlib", "flate"} {
+		path := "/streams/SomeStream/" + encoding
+		body := compress(c, encoding, er.PrettyPrint())
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "deflate")
+			w.Write(body)
+		})
+
+		got, _, err := client.GetEvent(path)
+		c.Assert(err, IsNil)
+		assertEventResponse(c, got, want)
+	}
+}
+
+func (s *CompressionSuite) TestCompressionCanBeDisabled(c *C) {
+	mux.HandleFunc("/streams/SomeStream/0", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("Accept-Encoding"), Not(Equals), "gzip, deflate")
+		fmt.Fprint(w, "{}")
+	})
+
+	client.SetCompression(false)
+	_, _, err := client.GetEvent("/streams/SomeStream/0")
+	c.Assert(err, IsNil)
+}
+
+func (s *CompressionSuite) TestLargeRequestBodiesAreCompressed(c *C) {
+	var encodings []string
+	var appended int
+	mux.HandleFunc("/streams/SomeStream", func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+
+		body := io.Reader(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			c.Assert(err, IsNil)
+			body = zr
+		}
+		var events []*goes.Event
+		c.Assert(json.NewDecoder(body).Decode(&events), IsNil)
+		appended += len(events)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	client.SetRequestCompression(1024)
+	writer := client.NewStreamWriter("SomeStream")
+
+	err := writer.Append(goes.ExpectedVersion{}, goes.NewEvent("", "FooEvent", &FooEvent{Foo: "bar"}, nil))
+	c.Assert(err, IsNil)
+
+	var events []*goes.Event
+	for i := 0; i < 100; i++ {
+		events = append(events, goes.NewEvent("", "FooEvent", &FooEvent{Foo: "bar"}, nil))
+	}
+	err = writer.Append(goes.ExpectedVersion{}, events...)
+	c.Assert(err, IsNil)
+
+	c.Assert(encodings, DeepEquals, []string{"", "gzip"})
+	c.Assert(appended, Equals, 101)
+
+	stats := client.TransferStats()
+	c.Assert(stats.BytesSent < stats.BytesSentUncompressed, Equals, true)
+}
+
+// Tests that the headers used for compression are set on the request sent and
+// not on the request passed to Do.
+func (s *CompressionSuite) TestDoDoesNotChangeTheRequest(c *C) {
+	mux.HandleFunc("/streams/SomeStream", func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Header.Get("Content-Encoding"), Equals, "gzip")
+		c.Check(r.Header.Get("Accept-Encoding"), Equals, "gzip, deflate")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	client.SetRequestCompression(16)
+	body := []*FooEvent{{Foo: "bar"}, {Foo: "baz"}}
+	req, err := client.NewRequest("POST", "/streams/SomeStream", body)
+	c.Assert(err, IsNil)
+	length := req.ContentLength
+
+	_, err = client.Do(req, nil)
+	c.Assert(err, IsNil)
+
+	c.Assert(req.Header.Get("Content-Encoding"), Equals, "")
+	c.Assert(req.Header.Get("Accept-Encoding"), Equals, "")
+	c.Assert(req.ContentLength, Equals, length)
+
+	var got []*FooEvent
+	c.Assert(json.NewDecoder(req.Body).Decode(&got), IsNil)
+	c.Assert(got, DeepEquals, body)
+}
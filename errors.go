@@ -5,6 +5,69 @@
 
 package goes
 
+import (
+	"errors"
+	"fmt"
+)
+
+// The errors returned by the client are pointers to the error types below.
+// Each type matches a sentinel error with errors.Is, whether it is returned
+// directly or wrapped, for example
+//
+//	if errors.Is(err, goes.ErrStreamNotFound) {
+//		...
+//	}
+//
+// The error values themselves can be retrieved with errors.As using a pointer
+// to a pointer of the type, for example
+//
+//	var nf *goes.ErrNotFound
+//	if errors.As(err, &nf) {
+//		...
+//	}
+//
+// Errors returned for HTTP errors unwrap to the *ErrorResponse, which can also
+// be retrieved with errors.As to inspect the details returned by the server.
+
+// The sentinel errors matched by the error types with errors.Is.
+var (
+	// ErrEndOfStream is matched by an ErrNoMoreEvents.
+	ErrEndOfStream = errors.New("There are no more events to load.")
+
+	// ErrStreamNotFound is matched by an ErrNotFound.
+	ErrStreamNotFound = errors.New("The stream does not exist.")
+
+	// ErrStreamDeleted is matched by an ErrDeleted.
+	ErrStreamDeleted = errors.New("The stream was deleted.")
+
+	// ErrNotAuthorized is matched by an ErrUnauthorized.
+	ErrNotAuthorized = errors.New("The request is not authorised.")
+
+	// ErrServerNotReady is matched by an ErrTemporarilyUnavailable.
+	ErrServerNotReady = errors.New("The server is not ready.")
+
+	// ErrUnexpectedResponse is matched by an ErrUnexpected.
+	ErrUnexpectedResponse = errors.New("An unexpected error occurred.")
+
+	// ErrInvalidRequest is matched by an ErrBadRequest.
+	ErrInvalidRequest = errors.New("Bad request.")
+
+	// ErrResourceConflict is matched by an ErrConflict.
+	ErrResourceConflict = errors.New("The request conflicts with the current state of the resource.")
+
+	// ErrWrongExpectedVersion is matched by an ErrConcurrencyViolation.
+	ErrWrongExpectedVersion = errors.New("The expected version does not match the version of the stream.")
+
+	// ErrLinkNotResolved is matched by an ErrUnresolvedLink.
+	ErrLinkNotResolved = errors.New("The link could not be resolved.")
+
+	// ErrFeedPageLoop is matched by an ErrFeedLoop.
+	ErrFeedPageLoop = errors.New("The feed page has already been read.")
+
+	// ErrResponseLimitExceeded is matched by an ErrResponseTooLarge.
+	ErrResponseLimitExceeded = errors.New("The response is larger than the limit.")
+)
+
 // ErrNoMoreEvents is returned when there are no events to return
 // from a request to a stream.
 type ErrNoMoreEvents struct{}
@@ -13,13 +76,28 @@ func (e ErrNoMoreEvents) Error() string {
 	return "There are no more events to load."
 }
 
+// Is reports whether target is ErrEndOfStream.
+func (e ErrNoMoreEvents) Is(target error) bool {
+	return target == ErrEndOfStream
+}
+
 // ErrNotFound is returned when a stream is not found.
 type ErrNotFound struct {
 	ErrorResponse *ErrorResponse
 }
 
 func (e ErrNotFound) Error() string {
-	return "The stream does not exist."
+	return describe("The stream does not exist.", e.ErrorResponse)
+}
+
+// Is reports whether target is ErrStreamNotFound.
+func (e ErrNotFound) Is(target error) bool {
+	return target == ErrStreamNotFound
+}
+
+// Unwrap returns the *ErrorResponse.
+func (e ErrNotFound) Unwrap() error {
+	return e.ErrorResponse.unwrap()
 }
 
 // ErrDeleted is returned when a request is made to a stream that
@@ -29,7 +107,17 @@ type ErrDeleted struct {
 }
 
 func (e ErrDeleted) Error() string {
-	return "The stream has was deleted."
+	return describe("The stream has was deleted.", e.ErrorResponse)
+}
+
+// Is reports whether target is ErrStreamDeleted.
+func (e ErrDeleted) Is(target error) bool {
+	return target == ErrStreamDeleted
+}
+
+// Unwrap returns the *ErrorResponse.
+func (e ErrDeleted) Unwrap() error {
+	return e.ErrorResponse.unwrap()
 }
 
 // ErrUnauthorized is returned when a request to the eventstore is
@@ -39,7 +127,17 @@ type ErrUnauthorized struct {
 }
 
 func (e ErrUnauthorized) Error() string {
-	return "You are not authorised to access the stream or the stream does not exist."
+	return describe("You are not authorised to access the stream or the stream does not exist.", e.ErrorResponse)
+}
+
+// Is reports whether target is ErrNotAuthorized.
+func (e ErrUnauthorized) Is(target error) bool {
+	return target == ErrNotAuthorized
+}
+
+// Unwrap returns the *ErrorResponse.
+func (e ErrUnauthorized) Unwrap() error {
+	return e.ErrorResponse.unwrap()
 }
 
 // ErrTemporarilyUnavailable is returned when the server returns ServiceUnavailable.
@@ -53,7 +151,17 @@ type ErrTemporarilyUnavailable struct {
 }
 
 func (e ErrTemporarilyUnavailable) Error() string {
-	return "Server Is Not Ready"
+	return describe("Server Is Not Ready", e.ErrorResponse)
+}
+
+// Is reports whether target is ErrServerNotReady.
+func (e ErrTemporarilyUnavailable) Is(target error) bool {
+	return target == ErrServerNotReady
+}
+
+// Unwrap returns the *ErrorResponse.
+func (e ErrTemporarilyUnavailable) Unwrap() error {
+	return e.ErrorResponse.unwrap()
 }
 
 // ErrUnexpected is returned when a request to the eventstore returns an error that
@@ -64,7 +172,17 @@ type ErrUnexpected struct {
 }
 
 func (e ErrUnexpected) Error() string {
-	return "An unexpected error occurred."
+	return describe("An unexpected error occurred.", e.ErrorResponse)
+}
+
+// Is reports whether target is ErrUnexpectedResponse.
+func (e ErrUnexpected) Is(target error) bool {
+	return target == ErrUnexpectedResponse
+}
+
+// Unwrap returns the *ErrorResponse.
+func (e ErrUnexpected) Unwrap() error {
+	return e.ErrorResponse.unwrap()
 }
 
 // ErrBadRequest is returned when the server returns a bad request error
@@ -73,15 +191,140 @@ type ErrBadRequest struct {
 }
 
 func (e ErrBadRequest) Error() string {
-	return "Bad request."
+	return describe("Bad request.", e.ErrorResponse)
+}
+
+// Is reports whether target is ErrInvalidRequest.
+func (e ErrBadRequest) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+// Unwrap returns the *ErrorResponse.
+func (e ErrBadRequest) Unwrap() error {
+	return e.ErrorResponse.unwrap()
+}
+
+// ErrConflict is returned by the UserService when the server returns a conflict
+// error, for example when creating a user that already exists. Conflicts
+// returned for other requests are returned as an ErrUnexpected.
+type ErrConflict struct {
+	ErrorResponse *ErrorResponse
+}
+
+func (e ErrConflict) Error() string {
+	return describe("The request conflicts with the current state of the resource.", e.ErrorResponse)
+}
+
+// Is reports whether target is ErrResourceConflict.
+func (e ErrConflict) Is(target error) bool {
+	return target == ErrResourceConflict
+}
+
+// Unwrap returns the *ErrorResponse.
+func (e ErrConflict) Unwrap() error {
+	return e.ErrorResponse.unwrap()
 }
 
 // ErrConcurrencyViolation is returned when the expected version does not match
 // the stream version when writing to an event stream.
+//
+// CurrentVersion is the version of the stream reported by the server, which is
+// -1 if the stream does not exist. HasCurrentVersion is false if the server did
+// not report the version, in which case CurrentVersion is 0.
 type ErrConcurrencyViolation struct {
-	ErrorResponse *ErrorResponse
+	ErrorResponse     *ErrorResponse
+	CurrentVersion    int
+	HasCurrentVersion bool
 }
 
 func (e ErrConcurrencyViolation) Error() string {
-	return "Concurrency Error."
+	if !e.HasCurrentVersion {
+		return describe("Concurrency Error. The current version of the stream was not reported.", e.ErrorResponse)
+	}
+	return describe(fmt.Sprintf("Concurrency Error. The current version of the stream is %d.", e.CurrentVersion), e.ErrorResponse)
+}
+
+// Is reports whether target is ErrWrongExpectedVersion.
+func (e ErrConcurrencyViolation) Is(target error) bool {
+	return target == ErrWrongExpectedVersion
+}
+
+// Unwrap returns the *ErrorResponse.
+func (e ErrConcurrencyViolation) Unwrap() error {
+	return e.ErrorResponse.unwrap()
+}
+
+// ErrUnresolvedLink is returned when a link event read with links resolved
+// points to an event that no longer exists, for example because the stream it
+// belongs to has been deleted or truncated.
+//
+// Link is the position of the link event in the stream being read and
+// TargetStreamID and TargetEventNumber identify the event it points to. Err is
+// the error returned when reading the event the link points to, if any.
+type ErrUnresolvedLink struct {
+	Link              EventLink
+	TargetStreamID    string
+	TargetEventNumber int
+	Err               error
+}
+
+func (e ErrUnresolvedLink) Error() string {
+	return fmt.Sprintf("The link %d@%s to %d@%s could not be resolved.",
+		e.Link.EventNumber, e.Link.StreamID, e.TargetEventNumber, e.TargetStreamID)
+}
+
+// Is reports whether target is ErrLinkNotResolved.
+func (e ErrUnresolvedLink) Is(target error) bool {
+	return target == ErrLinkNotResolved
+}
+
+// Unwrap returns the error returned when reading the event the link points to.
+func (e ErrUnresolvedLink) Unwrap() error {
+	return e.Err
+}
+
+// ErrFeedLoop is returned by a FeedPager when following links between feed
+// pages leads back to a page that has already been read.
+type ErrFeedLoop struct {
+	URL string
+}
+
+func (e ErrFeedLoop) Error() string {
+	return fmt.Sprintf("The feed page %s has already been read.", e.URL)
+}
+
+// Is reports whether target is ErrFeedPageLoop.
+func (e ErrFeedLoop) Is(target error) bool {
+	return target == ErrFeedPageLoop
+}
+
+// ErrResponseTooLarge is returned when a response body is larger than the
+// maximum response size set with SetMaxResponseSize.
+type ErrResponseTooLarge struct {
+	Limit int64
+}
+
+func (e ErrResponseTooLarge) Error() string {
+	return fmt.Sprintf("The response is larger than the limit of %d bytes.", e.Limit)
+}
+
+// Is reports whether target is ErrResponseLimitExceeded.
+func (e ErrResponseTooLarge) Is(target error) bool {
+	return target == ErrResponseLimitExceeded
+}
+
+// describe returns msg followed by the details of the error response.
+func describe(msg string, r *ErrorResponse) string {
+	if r == nil {
+		return msg
+	}
+	return fmt.Sprintf("%s (%s)", msg, r.details())
+}
+
+// unwrap returns r as an error, or nil if r is nil.
+func (r *ErrorResponse) unwrap() error {
+	if r == nil {
+		return nil
+	}
+	return r
 }
@@ -7,9 +7,12 @@ package goes
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"reflect"
 	"time"
 
+	"github.com/jetbasrawi/go.geteventstore/atom"
 	"github.com/jetbasrawi/go.geteventstore/internal/uuid"
 )
 
@@ -19,12 +22,20 @@ import (
 //
 // For more information on the server response see:
 // http://docs.geteventstore.com/http-api/latest/reading-streams/
+//
+// Link is set when the event was read through a link event, such as when
+// reading a category stream with links resolved, and holds the position of the
+// link in the stream that was read.
+//
+// Updated is the zero time if the server returned a time that could not be
+// parsed.
 type EventResponse struct {
 	Title   string
 	ID      string
-	Updated TimeStr
+	Updated time.Time
 	Summary string
 	Event   *Event
+	Link    *EventLink `json:",omitempty"`
 }
 
 // PrettyPrint renders an indented json view of the EventResponse.
@@ -92,11 +103,46 @@ type Link struct {
 }
 
 // TimeStr is a type used to format feed dates.
+//
+// The text of the time is kept as it is received from the server so that
+// sub-second precision and time zones are preserved. Use the Time method to
+// parse it as a time.Time.
 type TimeStr string
 
 // Time returns a TimeStr version of the time.Time argument t.
 func Time(t time.Time) TimeStr {
-	return TimeStr(t.Format("2006-01-02T15:04:05-07:00"))
+	return TimeStr(atom.Time(t))
+}
+
+// ParseTime parses a time in the format returned by the eventstore.
+func ParseTime(s string) (time.Time, error) {
+	return atom.ParseTime(s)
+}
+
+// Time parses the TimeStr as a time.Time in the same way as atom.TimeStr.
+func (t TimeStr) Time() (time.Time, error) {
+	return atom.TimeStr(t).Time()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t TimeStr) MarshalText() ([]byte, error) {
+	return atom.TimeStr(t).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler in the same way as
+// atom.TimeStr.
+func (t *TimeStr) UnmarshalText(b []byte) error {
+	return (*atom.TimeStr)(t).UnmarshalText(b)
+}
+
+// UnmarshalJSON implements json.Unmarshaler in the same way as atom.TimeStr.
+func (t *TimeStr) UnmarshalJSON(b []byte) error {
+	return (*atom.TimeStr)(t).UnmarshalJSON(b)
+}
+
+// UnmarshalXML implements xml.Unmarshaler in the same way as atom.TimeStr.
+func (t *TimeStr) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return (*atom.TimeStr)(t).UnmarshalXML(d, start)
 }
 
 // NewEvent creates a new event object.
@@ -124,6 +170,16 @@ func NewEvent(eventID, eventType string, data interface{}, meta interface{}) *Ev
 	return e
 }
 
+// NewLinkEvent creates a new link event that points to the event with event
+// number eventNumber in the stream streamName.
+//
+// Link events are written to a stream in the same way as other events. When the
+// stream is read with links resolved the event that the link points to is
+// returned in place of the link event.
+func NewLinkEvent(streamName string, eventNumber int) *Event {
+	return NewEvent("", LinkEventType, fmt.Sprintf("%d@%s", eventNumber, streamName), nil)
+}
+
 // NewUUID returns a new V4 uuid as a string.
 func NewUUID() string {
 	return uuid.NewV4().String()
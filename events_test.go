@@ -6,9 +6,14 @@
 package goes_test
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/jetbasrawi/go.geteventstore"
+	"github.com/jetbasrawi/go.geteventstore/atom"
 	. "gopkg.in/check.v1"
 )
 
@@ -64,3 +69,110 @@ func (s *EventSuite) TestNewEventUsesTypeNameAsEventTypeIfNotProvided(c *C) {
 
 	c.Assert(got.EventType, DeepEquals, reflect.TypeOf(data).Elem().Name())
 }
+
+func (s *EventSuite) TestTimeStrPreservesFractionalSecondsAndZone(c *C) {
+	t := time.Date(2016, 11, 22, 9, 55, 22, 259629700, time.FixedZone("", 3600))
+
+	ts := goes.Time(t)
+	c.Assert(ts, Equals, goes.TimeStr("2016-11-22T09:55:22.2596297+01:00"))
+
+	got, err := ts.Time()
+	c.Assert(err, IsNil)
+	c.Assert(got.Equal(t), Equals, true)
+	_, offset := got.Zone()
+	c.Assert(offset, Equals, 3600)
+
+	// Times without fractional seconds are formatted as before.
+	c.Assert(goes.Time(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)), Equals, goes.TimeStr("2016-01-01T00:00:00+00:00"))
+}
+
+func (s *EventSuite) TestParseTime(c *C) {
+	got, err := goes.ParseTime("2016-11-22T09:55:22.2596297Z")
+	c.Assert(err, IsNil)
+	c.Assert(got.Equal(time.Date(2016, 11, 22, 9, 55, 22, 259629700, time.UTC)), Equals, true)
+
+	got, err = goes.ParseTime("2016-11-22T09:55:22.25")
+	c.Assert(err, IsNil)
+	c.Assert(got.Equal(time.Date(2016, 11, 22, 9, 55, 22, 250000000, time.UTC)), Equals, true)
+
+	_, err = goes.ParseTime("22/11/2016")
+	c.Assert(err, NotNil)
+
+	got, err = goes.TimeStr("").Time()
+	c.Assert(err, IsNil)
+	c.Assert(got.IsZero(), Equals, true)
+}
+
+func (s *EventSuite) TestTimeStrJSON(c *C) {
+	var er goes.EventAtomResponse
+	err := json.Unmarshal([]byte(`{"updated":"2016-11-22T09:55:22.2596297Z"}`), &er)
+	c.Assert(err, IsNil)
+	c.Assert(er.Updated, Equals, goes.TimeStr("2016-11-22T09:55:22.2596297Z"))
+
+	b, err := json.Marshal(er.Updated)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, `"2016-11-22T09:55:22.2596297Z"`)
+
+	updated, err := er.Updated.Time()
+	c.Assert(err, IsNil)
+	c.Assert(updated.Nanosecond(), Equals, 259629700)
+
+	err = json.Unmarshal([]byte(`{"updated":null}`), &er)
+	c.Assert(err, IsNil)
+	c.Assert(er.Updated, Equals, goes.TimeStr("2016-11-22T09:55:22.2596297Z"))
+
+	// An invalid time does not fail the decoding, the error is returned when
+	// the time is parsed.
+	err = json.Unmarshal([]byte(`{"updated":"yesterday"}`), &er)
+	c.Assert(err, IsNil)
+	c.Assert(er.Updated, Equals, goes.TimeStr("yesterday"))
+	_, err = er.Updated.Time()
+	c.Assert(err, NotNil)
+}
+
+func (s *EventSuite) TestTimeStrXML(c *C) {
+	feed := `<feed xmlns="http://www.w3.org/2005/Atom">
+	<updated>2016-11-22T09:55:22.2596297Z</updated>
+	<entry>
+		<published>2016-11-22T09:55:21.1234567+02:00</published>
+		<updated>2016-11-22T09:55:22.2596297Z</updated>
+	</entry>
+</feed>`
+
+	var f atom.Feed
+	err := xml.Unmarshal([]byte(feed), &f)
+	c.Assert(err, IsNil)
+	c.Assert(f.Updated, Equals, atom.TimeStr("2016-11-22T09:55:22.2596297Z"))
+
+	published := f.Entry[0].Published
+	c.Assert(published.Equal(time.Date(2016, 11, 22, 7, 55, 21, 123456700, time.UTC)), Equals, true)
+	_, offset := published.Zone()
+	c.Assert(offset, Equals, 7200)
+
+	b, err := xml.Marshal(&f)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(b), "<published>2016-11-22T09:55:21.1234567+02:00</published>"), Equals, true)
+
+	var got atom.Feed
+	err = xml.Unmarshal(b, &got)
+	c.Assert(err, IsNil)
+	c.Assert(got.Entry[0].Published.Equal(published), Equals, true)
+	c.Assert(got.Entry[0].Updated, Equals, f.Entry[0].Updated)
+}
+
+func (s *EventSuite) TestInvalidPublishedTimeIsZero(c *C) {
+	var e atom.Entry
+	err := json.Unmarshal([]byte(`{"title":"0@stream","published":"yesterday","updated":"yesterday"}`), &e)
+	c.Assert(err, IsNil)
+	c.Assert(e.Title, Equals, "0@stream")
+	c.Assert(e.Published.IsZero(), Equals, true)
+	c.Assert(e.Updated, Equals, atom.TimeStr("yesterday"))
+
+	err = xml.Unmarshal([]byte(`<entry><title>0@stream</title><published>yesterday</published></entry>`), &e)
+	c.Assert(err, IsNil)
+	c.Assert(e.Published.IsZero(), Equals, true)
+
+	b, err := json.Marshal(&e)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(b), `"published"`), Equals, false)
+}
@@ -0,0 +1,185 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"context"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore/atom"
+)
+
+// subscribePollInterval is the time a subscription waits before polling the
+// head of the stream again when the reader is not long polling.
+const subscribePollInterval = time.Second
+
+// EventResult is delivered for each event read from a stream by Events or
+// Subscribe.
+//
+// Exactly one of EventResponse and Err will be set, except for an
+// *ErrUnresolvedLink which is delivered with the link event as the
+// EventResponse. Any other error is always the last result delivered before
+// the channel is closed.
+type EventResult struct {
+	EventResponse *EventResponse
+	Err           error
+}
+
+// Scan deserializes the event data and metadata of the result into the
+// types passed in as arguments e and m.
+func (r *EventResult) Scan(e interface{}, m interface{}) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	return scanEvent(r.EventResponse, e, m)
+}
+
+// Events returns a channel on which the events of the stream are delivered
+// in order, starting at the reader's next version.
+//
+// The channel is closed when:
+//
+// the end of the stream is reached,
+//
+// an error occurs, in which case the error is delivered as the last result
+// unless it is an *ErrUnresolvedLink,
+//
+// or ctx is cancelled, in which case no further results are delivered and any
+// request in flight is cancelled.
+//
+// The channel is unbuffered so events are only read from the server as fast
+// as they are received from the channel. The reader must not be used by any
+// other goroutine until the channel has been closed. When the channel is
+// closed the reader is positioned after the last event delivered and can be
+// used to continue reading the stream.
+func (s *StreamReader) Events(ctx context.Context) <-chan *EventResult {
+	return s.stream(ctx, false)
+}
+
+// Subscribe returns a channel on which the events of the stream are delivered
+// in order, starting at the reader's next version, and continues to deliver new
+// events as they are written to the stream.
+//
+// When the reader reaches the head of the stream it waits for new events. If
+// LongPoll has been set on the reader the server will hold the request open
+// until new events arrive, otherwise the reader polls the head of the stream
+// at one second intervals.
+//
+// The channel is closed when ctx is cancelled or after an error other than an
+// *ErrUnresolvedLink is delivered. Subscribe has the same backpressure semantics as Events.
+func (s *StreamReader) Subscribe(ctx context.Context) <-chan *EventResult {
+	return s.stream(ctx, true)
+}
+
+func (s *StreamReader) stream(ctx context.Context, follow bool) <-chan *EventResult {
+	ch := make(chan *EventResult)
+
+	go func() {
+		defer close(ch)
+
+		// Any events read ahead by the reader are discarded so that reading
+		// continues with requests made with ctx.
+		s.stopPrefetch()
+		s.ctx = ctx
+		defer func() {
+			s.stopPrefetch()
+			s.ctx = nil
+		}()
+
+		for ctx.Err() == nil {
+			pos := s.position()
+			s.Next()
+
+			// An event or error read after cancellation is not delivered and
+			// the reader is returned to the position before it was read.
+			if ctx.Err() != nil {
+				s.stopPrefetch()
+				s.seek(pos)
+				return
+			}
+
+			err := s.Err()
+			if _, ok := err.(*ErrNoMoreEvents); ok {
+				if !follow {
+					return
+				}
+				if s.longPoll <= 0 {
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(subscribePollInterval):
+					}
+				}
+				continue
+			}
+
+			// Unresolved links are delivered with the link event and reading
+			// continues as the reader has advanced past them.
+			_, unresolved := err.(*ErrUnresolvedLink)
+
+			r := &EventResult{Err: err}
+			if err == nil || unresolved {
+				r.EventResponse = s.EventResponse()
+			}
+
+			select {
+			case ch <- r:
+			case <-ctx.Done():
+				s.stopPrefetch()
+				s.seek(pos)
+				return
+			}
+
+			if err != nil && !unresolved {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// readerPosition records the position of a StreamReader.
+type readerPosition struct {
+	version       int
+	nextVersion   int
+	index         int
+	currentURL    string
+	pageSize      int
+	feedPageSize  int
+	feedPage      *atom.Feed
+	pageEvents    []*EventResult
+	eventResponse *EventResponse
+	lasterr       error
+}
+
+func (s *StreamReader) position() readerPosition {
+	return readerPosition{
+		version:       s.version,
+		nextVersion:   s.nextVersion,
+		index:         s.index,
+		currentURL:    s.currentURL,
+		pageSize:      s.pageSize,
+		feedPageSize:  s.feedPageSize,
+		feedPage:      s.feedPage,
+		pageEvents:    s.pageEvents,
+		eventResponse: s.eventResponse,
+		lasterr:       s.lasterr,
+	}
+}
+
+func (s *StreamReader) seek(p readerPosition) {
+	s.version = p.version
+	s.nextVersion = p.nextVersion
+	s.index = p.index
+	s.currentURL = p.currentURL
+	s.pageSize = p.pageSize
+	s.feedPageSize = p.feedPageSize
+	s.feedPage = p.feedPage
+	s.pageEvents = p.pageEvents
+	s.eventResponse = p.eventResponse
+	s.lasterr = p.lasterr
+}
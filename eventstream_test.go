@@ -0,0 +1,139 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&EventStreamSuite{})
+
+type EventStreamSuite struct{}
+
+func (s *EventStreamSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *EventStreamSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+// Tests that all of the events in the stream are delivered in order and that
+// the channel is closed at the end of the stream.
+func (s *EventStreamSuite) TestEventsDeliversAllEventsAndCloses(c *C) {
+	streamName := "SomeStream"
+	ne := 25
+	es := mock.CreateTestEvents(ne, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	reader := client.NewStreamReader(streamName)
+	count := 0
+	for r := range reader.Events(context.Background()) {
+		c.Assert(r.Err, IsNil)
+		c.Assert(r.EventResponse.Event.EventNumber, Equals, count)
+
+		var got, want FooEvent
+		c.Assert(r.Scan(&got, nil), IsNil)
+		err := json.Unmarshal(*es[count].Data.(*json.RawMessage), &want)
+		c.Assert(err, IsNil)
+		c.Assert(got, DeepEquals, want)
+		count++
+	}
+	c.Assert(count, Equals, ne)
+	c.Assert(reader.Version(), Equals, ne-1)
+}
+
+// Tests that an error is delivered as the last result before the channel
+// is closed.
+func (s *EventStreamSuite) TestEventsDeliversErrorAndCloses(c *C) {
+	reader := client.NewStreamReader("DoesNotExist")
+	var results []error
+	for r := range reader.Events(context.Background()) {
+		c.Assert(r.EventResponse, IsNil)
+		results = append(results, r.Err)
+	}
+	c.Assert(results, HasLen, 1)
+	c.Assert(reflect.TypeOf(results[0]).Elem().Name(), Equals, "ErrNotFound")
+}
+
+// Tests that cancelling the context closes the channel and leaves the reader
+// positioned after the last event delivered.
+func (s *EventStreamSuite) TestEventsCancellation(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(10, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := client.NewStreamReader(streamName)
+	ch := reader.Events(ctx)
+
+	for i := 0; i < 3; i++ {
+		r := <-ch
+		c.Assert(r.Err, IsNil)
+		c.Assert(r.EventResponse.Event.EventNumber, Equals, i)
+	}
+	cancel()
+
+	// Drain any result that was sent before the cancellation was observed.
+	last := 2
+	for r := range ch {
+		last = r.EventResponse.Event.EventNumber
+	}
+
+	c.Assert(reader.Version(), Equals, last)
+	c.Assert(reader.Next(), Equals, true)
+	c.Assert(reader.Err(), IsNil)
+	c.Assert(reader.EventResponse().Event.EventNumber, Equals, last+1)
+}
+
+// Tests that a subscription waits at the head of the stream and delivers
+// events written after the head of the stream was reached.
+func (s *EventStreamSuite) TestSubscribeDeliversNewEvents(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(3, streamName, server.URL, "FooEvent")
+	u, _ := url.Parse(server.URL)
+
+	var mu sync.Mutex
+	sim, _ := mock.NewAtomFeedSimulator(es[:2], u, nil, -1)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		h := sim
+		mu.Unlock()
+		h.ServeHTTP(w, r)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reader := client.NewStreamReader(streamName)
+	ch := reader.Subscribe(ctx)
+	for i := 0; i < 2; i++ {
+		r := <-ch
+		c.Assert(r.Err, IsNil)
+		c.Assert(r.EventResponse.Event.EventNumber, Equals, i)
+	}
+
+	mu.Lock()
+	sim, _ = mock.NewAtomFeedSimulator(es, u, nil, -1)
+	mu.Unlock()
+
+	r := <-ch
+	c.Assert(r, NotNil)
+	c.Assert(r.Err, IsNil)
+	c.Assert(r.EventResponse.Event.EventNumber, Equals, 2)
+
+	cancel()
+	for range ch {
+	}
+}
@@ -114,9 +114,9 @@ func writeEvents(client *goes.Client, streamName string) {
 
 	// Write the events to the stream
 	// The first argument allows you to specify the expected version. Here expected version
-	// is nil and so the events will be appended at the head of the stream regardless of the
+	// is Any and so the events will be appended at the head of the stream regardless of the
 	// version of the stream.
-	err := writer.Append(nil, goesEvent1, goesEvent2)
+	err := writer.Append(goes.Any(), goesEvent1, goesEvent2)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -127,8 +127,7 @@ func writeEvents(client *goes.Client, streamName string) {
 	// Lets repeat this but using an expected version that will cause an error
 	// to demonstrate handling concurrency errors
 	// This should result in a goes.ErrConcurrencyViolation
-	v := 0
-	err = writer.Append(&v, goesEvent1)
+	err = writer.Append(goes.Exact(0), goesEvent1)
 	if err != nil {
 		log.Printf(" - Received expected error. %#v\n", err)
 	}
@@ -31,7 +31,7 @@ func main() {
 	log.Println("1. Write an event to a new stream.")
 	writer := client.NewStreamWriter(streamName)
 	ev1 := goes.NewEvent("", "", &FooEvent{"Event 1"}, nil)
-	err = writer.Append(nil, ev1)
+	err = writer.Append(goes.Any(), ev1)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -87,7 +87,7 @@ func main() {
 	// This should result in the stream being undeleted and the second event
 	// being appended to the stream.
 	ev2 := goes.NewEvent("", "", &FooEvent{"Event 2"}, nil)
-	err = writer.Append(nil, ev2)
+	err = writer.Append(goes.Any(), ev2)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -127,7 +127,7 @@ func main() {
 
 	log.Println("9. Try to write to the hard deleted stream. This should result in an ErrDeleted")
 	ev3 := goes.NewEvent("", "", &FooEvent{"Event 3"}, nil)
-	err = writer.Append(nil, ev3)
+	err = writer.Append(goes.Any(), ev3)
 	if err != nil {
 		if _, ok := err.(*goes.ErrDeleted); ok {
 			log.Println(" - As expected, an attempt to write to the hard deleted stream fails.")
@@ -169,9 +169,9 @@ func writeEvents(client *goes.Client, streamName string) {
 
 	// Write the events to the stream
 	// The first argument allows you to specify the expected version. Here expected version
-	// is nil and so the events will be appended at the head of the stream regardless of the
+	// is Any and so the events will be appended at the head of the stream regardless of the
 	// version of the stream.
-	err := writer.Append(nil, goesEvent1, goesEvent2)
+	err := writer.Append(goes.Any(), goesEvent1, goesEvent2)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -182,8 +182,7 @@ func writeEvents(client *goes.Client, streamName string) {
 	// Lets repeat this but using an expected version that will cause an error
 	// to demonstrate handling concurrency errors
 	// This should result in a goes.ErrConcurrencyViolation
-	v := 0
-	err = writer.Append(&v, goesEvent1)
+	err = writer.Append(goes.Exact(0), goesEvent1)
 	if err != nil {
 		log.Printf(" - Received expected error. %#v\n", err)
 	}
@@ -196,6 +195,11 @@ func readEvents(client *goes.Client, streamName string) {
 	// Create a new stream reader
 	reader := client.NewStreamReader(streamName)
 
+	// The events of each feed page can be read concurrently. Here up to
+	// 5 events will be read from the eventstore at the same time. Events are
+	// still returned by Next in stream order.
+	reader.FetchConcurrency(5)
+
 	// To begin reading from a stream from a specific version call the
 	// NextVersion(int) method.
 	// After a call to next, the event returned will be the event at the version
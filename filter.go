@@ -0,0 +1,148 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore/atom"
+)
+
+// EventFilter decides whether an event read by a StreamReader is returned to
+// the caller.
+//
+// Filters on event type and stream name are applied to the entries of the feed
+// page so that events that are excluded are skipped without reading the event
+// from the eventstore. Filters on metadata are applied once the event has been
+// read.
+type EventFilter struct {
+	eventType  func(eventType string) bool
+	streamName func(streamName string) bool
+	event      func(e *EventResponse) bool
+}
+
+// AllowEventTypes returns a filter that accepts only events of the event types
+// provided.
+func AllowEventTypes(eventTypes ...string) EventFilter {
+	types := stringSet(eventTypes)
+	return EventFilter{
+		eventType: func(eventType string) bool {
+			return types[eventType]
+		},
+	}
+}
+
+// DenyEventTypes returns a filter that accepts all events except those of the
+// event types provided.
+func DenyEventTypes(eventTypes ...string) EventFilter {
+	types := stringSet(eventTypes)
+	return EventFilter{
+		eventType: func(eventType string) bool {
+			return !types[eventType]
+		},
+	}
+}
+
+// StreamNameMatches returns a filter that accepts only events that were written
+// to a stream whose name matches the regular expression.
+//
+// This is useful when reading streams such as $all or projection streams that
+// contain events from many streams.
+func StreamNameMatches(re *regexp.Regexp) EventFilter {
+	return EventFilter{
+		streamName: re.MatchString,
+	}
+}
+
+// MetaDataMatches returns a filter that accepts only events for which the
+// predicate returns true. The predicate is passed the raw event metadata which
+// will be nil if the event has no metadata.
+func MetaDataMatches(predicate func(meta json.RawMessage) bool) EventFilter {
+	return EventFilter{
+		event: func(e *EventResponse) bool {
+			var meta json.RawMessage
+			if e.Event != nil {
+				if m, ok := e.Event.MetaData.(*json.RawMessage); ok && m != nil {
+					meta = *m
+				}
+			}
+			return predicate(meta)
+		},
+	}
+}
+
+// Filter sets the filters applied to the events read by the reader.
+//
+// Only events accepted by all of the filters are returned by Next. Events that
+// are skipped still advance the version of the reader. Calling Filter with no
+// arguments removes any filters from the reader.
+func (s *StreamReader) Filter(filters ...EventFilter) {
+	s.stopPrefetch()
+	s.filters = filters
+
+	// Events of the current page read concurrently under the previous filters
+	// are discarded as they may not include events accepted by the new filters.
+	s.pageEvents = nil
+}
+
+// matchEntry reports whether the feed entry is accepted by the reader's
+// filters using the information in the entry.
+//
+// The event type of the entry is taken from the summary and the stream name is
+// taken from the title which has the form eventnumber@streamname. Filters that
+// cannot be decided from the entry are left to matchEvent.
+func (s *StreamReader) matchEntry(entry *atom.Entry) bool {
+	if len(s.filters) == 0 {
+		return true
+	}
+
+	eventType := ""
+	if entry.Summary != nil {
+		eventType = entry.Summary.Body
+	}
+
+	streamName := s.streamName
+	if i := strings.Index(entry.Title, "@"); i >= 0 {
+		streamName = entry.Title[i+1:]
+	}
+
+	for _, f := range s.filters {
+		if f.eventType != nil && eventType != "" && !f.eventType(eventType) {
+			return false
+		}
+		if f.streamName != nil && !f.streamName(streamName) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchEvent reports whether the event is accepted by the reader's filters.
+func (s *StreamReader) matchEvent(e *EventResponse) bool {
+	if len(s.filters) == 0 {
+		return true
+	}
+
+	for _, f := range s.filters {
+		if f.eventType != nil && e.Event != nil && !f.eventType(e.Event.EventType) {
+			return false
+		}
+		if f.event != nil && !f.event(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,228 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&FilterSuite{})
+
+type FilterSuite struct{}
+
+func (s *FilterSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *FilterSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+// setupCountingSimulator serves the events with the feed simulator and
+// records the paths of the events that are read.
+func setupCountingSimulator(es []*mock.Event) func() []string {
+	u, _ := url.Parse(server.URL)
+	sim, _ := mock.NewAtomFeedSimulator(es, u, nil, -1)
+
+	var mu sync.Mutex
+	var reads []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "forward") {
+			mu.Lock()
+			reads = append(reads, r.URL.Path)
+			mu.Unlock()
+		}
+		sim.ServeHTTP(w, r)
+	})
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), reads...)
+	}
+}
+
+// Tests that events of other types are skipped without being read.
+func (s *FilterSuite) TestAllowEventTypes(c *C) {
+	streamName := "SomeStream"
+	ne := 30
+	es := mock.CreateTestEvents(ne, streamName, server.URL, "FooEvent", "BarEvent")
+	reads := setupCountingSimulator(es)
+
+	var want []int
+	for _, e := range es {
+		if e.EventType == "FooEvent" {
+			want = append(want, e.EventNumber)
+		}
+	}
+
+	stream := client.NewStreamReader(streamName)
+	stream.Filter(goes.AllowEventTypes("FooEvent"))
+
+	var got []int
+	for {
+		stream.Next()
+		if stream.Err() != nil {
+			break
+		}
+		c.Assert(stream.EventResponse().Event.EventType, Equals, "FooEvent")
+		c.Assert(stream.Version(), Equals, stream.EventResponse().Event.EventNumber)
+		got = append(got, stream.Version())
+	}
+	c.Assert(stream.Err(), DeepEquals, &goes.ErrNoMoreEvents{})
+	c.Assert(got, DeepEquals, want)
+	c.Assert(reads(), HasLen, len(want))
+
+	// Skipped events advance the version of the reader.
+	c.Assert(stream.Version(), Equals, ne-1)
+}
+
+func (s *FilterSuite) TestDenyEventTypes(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(30, streamName, server.URL, "FooEvent", "BarEvent")
+	reads := setupCountingSimulator(es)
+
+	want := 0
+	for _, e := range es {
+		if e.EventType != "BarEvent" {
+			want++
+		}
+	}
+
+	stream := client.NewStreamReader(streamName)
+	stream.Filter(goes.DenyEventTypes("BarEvent"))
+
+	got := 0
+	for stream.Next() {
+		if stream.Err() != nil {
+			break
+		}
+		c.Assert(stream.EventResponse().Event.EventType, Not(Equals), "BarEvent")
+		got++
+	}
+	c.Assert(got, Equals, want)
+	c.Assert(reads(), HasLen, want)
+}
+
+// Tests that the stream name is taken from the title of the entries of a feed
+// that contains events from many streams.
+func (s *FilterSuite) TestStreamNameMatches(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(20, streamName, server.URL, "FooEvent")
+	for i, e := range es {
+		if i%2 == 1 {
+			e.EventStreamID = fmt.Sprintf("order-%d", i)
+		}
+	}
+	reads := setupCountingSimulator(es)
+
+	stream := client.NewStreamReader(streamName)
+	stream.Filter(goes.StreamNameMatches(regexp.MustCompile(`^order-`)))
+
+	got := 0
+	for stream.Next() {
+		if stream.Err() != nil {
+			break
+		}
+		c.Assert(stream.EventResponse().Event.EventStreamID, Matches, "order-.*")
+		got++
+	}
+	c.Assert(got, Equals, 10)
+	c.Assert(reads(), HasLen, 10)
+}
+
+func (s *FilterSuite) TestMetaDataMatches(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(10, streamName, server.URL, "FooEvent")
+	for i, e := range es {
+		m := json.RawMessage(fmt.Sprintf(`{"tenant":"t%d"}`, i%3))
+		e.MetaData = &m
+	}
+	setupCountingSimulator(es)
+
+	stream := client.NewStreamReader(streamName)
+	stream.Filter(goes.MetaDataMatches(func(meta json.RawMessage) bool {
+		var m struct {
+			Tenant string `json:"tenant"`
+		}
+		if err := json.Unmarshal(meta, &m); err != nil {
+			return false
+		}
+		return m.Tenant == "t0"
+	}))
+
+	var got []int
+	for stream.Next() {
+		if stream.Err() != nil {
+			break
+		}
+		got = append(got, stream.Version())
+	}
+	c.Assert(got, DeepEquals, []int{0, 3, 6, 9})
+}
+
+// Tests that events read concurrently are filtered before they are read.
+func (s *FilterSuite) TestFilterWithFetchConcurrency(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(45, streamName, server.URL, "FooEvent", "BarEvent")
+	reads := setupCountingSimulator(es)
+
+	want := 0
+	for _, e := range es {
+		if e.EventType == "FooEvent" {
+			want++
+		}
+	}
+
+	stream := client.NewStreamReader(streamName)
+	stream.FetchConcurrency(4)
+	stream.Filter(goes.AllowEventTypes("FooEvent"))
+
+	got := 0
+	for stream.Next() {
+		if stream.Err() != nil {
+			break
+		}
+		c.Assert(stream.EventResponse().Event.EventType, Equals, "FooEvent")
+		got++
+	}
+	c.Assert(got, Equals, want)
+	c.Assert(reads(), HasLen, want)
+}
+
+func (s *FilterSuite) TestFilterAppliesToEvents(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(25, streamName, server.URL, "FooEvent", "BarEvent")
+	setupCountingSimulator(es)
+
+	want := 0
+	for _, e := range es {
+		if e.EventType == "BarEvent" {
+			want++
+		}
+	}
+
+	stream := client.NewStreamReader(streamName)
+	stream.Filter(goes.AllowEventTypes("BarEvent"))
+
+	got := 0
+	for r := range stream.Events(context.Background()) {
+		c.Assert(r.Err, IsNil)
+		c.Assert(r.EventResponse.Event.EventType, Equals, "BarEvent")
+		got++
+	}
+	c.Assert(got, Equals, want)
+}
@@ -6,14 +6,22 @@
 package goes_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jetbasrawi/go.geteventstore"
 	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	"github.com/jetbasrawi/go.geteventstore/atom"
 
 	. "gopkg.in/check.v1"
 )
@@ -63,6 +71,118 @@ func setupSimulator(es []*mock.Event, m *mock.Event) {
 	mux.Handle("/", handler)
 }
 
+var (
+	pagePath  = regexp.MustCompile(`^/streams/([^/]+)/(head|\d+)/(forward|backward)/(\d+)$`)
+	eventPath = regexp.MustCompile(`^/streams/([^/]+)/(\d+)$`)
+)
+
+// gapSimulator serves a stream whose events have the event numbers provided,
+// such as a stream that has been truncated or scavenged. Feed pages are served
+// by position in the same way as the eventstore, starting at the first event
+// number at or after the version requested when reading forward. Each event
+// was written n minutes after 2016-01-01 where n is its event number.
+func gapSimulator(stream string, numbers []int) http.Handler {
+	numbers = append([]int(nil), numbers...)
+	sort.Ints(numbers)
+	events := make(map[int]*mock.Event)
+	for _, n := range numbers {
+		events[n] = mock.CreateTestEvent(stream, server.URL, "FooEvent", n, nil, nil)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m := eventPath.FindStringSubmatch(r.URL.Path); m != nil && m[1] == stream {
+			n, _ := strconv.Atoi(m[2])
+			e, ok := events[n]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			t := mock.Time(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(n) * time.Minute))
+			er, _ := mock.CreateTestEventAtomResponse(e, &t)
+			fmt.Fprint(w, er.PrettyPrint())
+			return
+		}
+
+		m := pagePath.FindStringSubmatch(r.URL.Path)
+		if m == nil || m[1] != stream || len(numbers) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		size, _ := strconv.Atoi(m[4])
+
+		// from and to are the indexes of the first and last events of the page.
+		var from, to int
+		switch v, _ := strconv.Atoi(m[2]); {
+		case m[2] == "head":
+			to = len(numbers)
+			from = to - size
+		case m[3] == "forward":
+			from = sort.SearchInts(numbers, v)
+			to = from + size
+		default:
+			to = sort.SearchInts(numbers, v+1)
+			from = to - size
+		}
+		if from < 0 {
+			from = 0
+		}
+		if to > len(numbers) {
+			to = len(numbers)
+		}
+		page := numbers[from:to]
+
+		var es []*mock.Event
+		for _, n := range page {
+			es = append(es, events[n])
+		}
+		su := fmt.Sprintf("%s/streams/%s", server.URL, stream)
+		f, _ := mock.CreateTestFeed(es, server.URL+r.URL.Path)
+		f.StreamID = stream
+		f.HeadOfStream = len(page) == 0 || page[len(page)-1] == numbers[len(numbers)-1]
+		f.Link = append(f.Link,
+			atom.Link{Rel: "first", Href: fmt.Sprintf("%s/head/backward/%d", su, size)},
+			atom.Link{Rel: "last", Href: fmt.Sprintf("%s/0/forward/%d", su, size)})
+		if len(page) > 0 {
+			f.Link = append(f.Link, atom.Link{Rel: "previous", Href: fmt.Sprintf("%s/%d/forward/%d", su, page[len(page)-1]+1, size)})
+			if page[0] > numbers[0] {
+				f.Link = append(f.Link, atom.Link{Rel: "next", Href: fmt.Sprintf("%s/%d/backward/%d", su, page[0]-1, size)})
+			}
+		} else {
+			f.Link = append(f.Link, atom.Link{Rel: "previous", Href: su + "/" + m[2] + "/forward/" + m[4]})
+		}
+		w.Header().Set("Content-Type", "application/atom+xml")
+		fmt.Fprint(w, f.PrettyPrint())
+	})
+}
+
+// capPageSize limits the number of entries of the feed pages served by h to
+// max, in the same way as the eventstore limits the size of feed pages.
+func capPageSize(h http.Handler, max int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m := pagePath.FindStringSubmatch(r.URL.Path); m != nil {
+			if n, _ := strconv.Atoi(m[4]); n > max {
+				r.URL.Path = strings.TrimSuffix(r.URL.Path, m[4]) + strconv.Itoa(max)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// assertEventResponse asserts that the event response got matches the event
+// response want created by the mock. The mock keeps the updated time as text.
+func assertEventResponse(c *C, got *goes.EventResponse, want *mock.EventResponse) {
+	updated, err := goes.TimeStr(want.Updated).Time()
+	c.Assert(err, IsNil)
+	c.Assert(got.Updated.Equal(updated), Equals, true)
+	c.Assert(got.Title, Equals, want.Title)
+	c.Assert(got.ID, Equals, want.ID)
+	c.Assert(got.Summary, Equals, want.Summary)
+
+	event, err := json.MarshalIndent(want.Event, "", "	")
+	c.Assert(err, IsNil)
+	c.Assert(got.Event.PrettyPrint(), Equals, string(event))
+}
+
 func teardown() {
 	server.Close()
 }
@@ -0,0 +1,195 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jetbasrawi/go.geteventstore/atom"
+)
+
+// LinkEventType is the event type of link events.
+//
+// The data of a link event is a pointer to another event in the form
+// eventnumber@streamname. The system projections such as $by_category and
+// $by_event_type write link events to the streams they create.
+const LinkEventType = "$>"
+
+// EventLink identifies the link event through which an event was read.
+//
+// When a stream of link events such as a category stream is read with links
+// resolved, the events returned belong to their original streams. StreamID and
+// EventNumber are the position of the link in the stream that was read while
+// the EventStreamID and EventNumber of the event are its position in the
+// original stream.
+//
+// MetaData is the metadata of the link event. It is only available when the
+// link has been resolved with ResolveLink.
+type EventLink struct {
+	StreamID    string
+	EventNumber int
+	MetaData    interface{} `json:",omitempty"`
+}
+
+// NewCategoryReader returns a *StreamReader for the category stream of the
+// category provided.
+//
+// Category streams, named $ce-{category}, are created by the $by_category
+// system projection and contain link events to the events of all the streams
+// in the category. The reader resolves the links so that the events returned
+// are the original events. See StreamReader.ResolveLinkTos.
+func (c *Client) NewCategoryReader(category string, opts ...RequestOption) *StreamReader {
+	s := c.NewStreamReader("$ce-"+category, opts...)
+	s.resolveLinks = true
+	return s
+}
+
+// NewEventTypeReader returns a *StreamReader for the event type stream of the
+// event type provided.
+//
+// Event type streams, named $et-{eventtype}, are created by the $by_event_type
+// system projection and contain link events to all the events of that type.
+// The reader resolves the links so that the events returned are the original
+// events. See StreamReader.ResolveLinkTos.
+func (c *Client) NewEventTypeReader(eventType string, opts ...RequestOption) *StreamReader {
+	s := c.NewStreamReader("$et-"+eventType, opts...)
+	s.resolveLinks = true
+	return s
+}
+
+// ResolveLinkTos sets whether the reader resolves link events.
+//
+// When links are resolved, the events returned by the reader are the events
+// that the links point to and the EventResponse Link field holds the position
+// of the link in the stream being read. If a link cannot be resolved because the
+// event it points to has been deleted, Next returns an *ErrUnresolvedLink with
+// the link event as the EventResponse. The reader advances past unresolved links
+// so that reading can continue.
+func (s *StreamReader) ResolveLinkTos(resolve bool) {
+	s.stopPrefetch()
+	s.resolveLinks = resolve
+}
+
+// ResolveLink reads the event that the link event provided points to.
+//
+// The event returned has its Link field set to the position and metadata of the
+// link event. If the link cannot be resolved because the event it points to has
+// been deleted or its stream has been deleted or truncated, an
+// *ErrUnresolvedLink is returned with the error returned by the server as its
+// Err field.
+func (c *Client) ResolveLink(link *EventResponse, opts ...RequestOption) (*EventResponse, *Response, error) {
+	if link == nil || link.Event == nil || link.Event.EventType != LinkEventType {
+		return nil, nil, fmt.Errorf("The event is not a link event.")
+	}
+
+	stream, number, err := parseLinkData(link.Event.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e, resp, err := c.GetEvent(fmt.Sprintf("/streams/%s/%d", stream, number), opts...)
+	if err != nil {
+		switch err.(type) {
+		case *ErrNotFound, *ErrDeleted:
+			return nil, resp, &ErrUnresolvedLink{
+				Link: EventLink{
+					StreamID:    link.Event.EventStreamID,
+					EventNumber: link.Event.EventNumber,
+				},
+				TargetStreamID:    stream,
+				TargetEventNumber: number,
+				Err:               err,
+			}
+		}
+		return nil, resp, err
+	}
+
+	e.Link = &EventLink{
+		StreamID:    link.Event.EventStreamID,
+		EventNumber: link.Event.EventNumber,
+		MetaData:    link.Event.MetaData,
+	}
+	return e, resp, nil
+}
+
+// ResolveLink reads the event that the current event of the reader points to
+// if the current event is a link event. See Client.ResolveLink.
+//
+// The reader's options are used for the request and its position is
+// unchanged.
+func (s *StreamReader) ResolveLink() (*EventResponse, error) {
+	if s.eventResponse == nil {
+		return nil, &ErrNoMoreEvents{}
+	}
+	e, _, err := s.client.ResolveLink(s.eventResponse, s.requestOptions()...)
+	return e, err
+}
+
+// resolveLink checks an event read for a feed entry when links are being
+// resolved.
+//
+// If the event was read through a link, the position of the link, taken from
+// the entry, is recorded on the event response. If the event is a link event,
+// the link could not be resolved and an *ErrUnresolvedLink is returned.
+func (s *StreamReader) resolveLink(e *EventResponse, entry *atom.Entry) error {
+	if !s.resolveLinks || e.Event == nil {
+		return nil
+	}
+
+	// The reader's version is used if the position cannot be determined from
+	// the entry.
+	position, ok := entryPosition(s.streamName, entry)
+	if !ok {
+		position = s.nextVersion
+	}
+
+	link := &EventLink{StreamID: s.streamName, EventNumber: position}
+	if e.Event.EventType == LinkEventType {
+		err := &ErrUnresolvedLink{Link: *link}
+		err.TargetStreamID, err.TargetEventNumber, _ = parseLinkData(e.Event.Data)
+		return err
+	}
+
+	if e.Event.EventStreamID != s.streamName {
+		e.Link = link
+	}
+	return nil
+}
+
+// parseLinkData parses the data of a link event which has the form
+// eventnumber@streamname.
+func parseLinkData(data interface{}) (string, int, error) {
+	var text string
+	switch d := data.(type) {
+	case string:
+		text = d
+	case *json.RawMessage:
+		if d == nil {
+			break
+		}
+		// The data of a link event is returned as a json string.
+		if err := json.Unmarshal(*d, &text); err != nil {
+			text = string(*d)
+		}
+	case json.RawMessage:
+		if err := json.Unmarshal(d, &text); err != nil {
+			text = string(d)
+		}
+	}
+
+	parts := strings.SplitN(text, "@", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", 0, fmt.Errorf("Invalid link data %q.", text)
+	}
+	n, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", 0, fmt.Errorf("Invalid link data %q.", text)
+	}
+	return parts[1], n, nil
+}
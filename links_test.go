@@ -0,0 +1,273 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&LinksSuite{})
+
+type LinksSuite struct{}
+
+func (s *LinksSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *LinksSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+// setupLinkSimulator serves a stream of ne link events. The link at event
+// number n points to event 2n of stream order-n. The links in deleted cannot
+// be resolved.
+func setupLinkSimulator(c *C, streamName string, ne int, deleted ...int) {
+	mux.Handle("/", linkHandler(c, streamName, ne, deleted...))
+}
+
+// linkHandler serves the link stream described for setupLinkSimulator.
+func linkHandler(c *C, streamName string, ne int, deleted ...int) http.Handler {
+	links := mock.CreateTestEvents(ne, streamName, server.URL, goes.LinkEventType)
+	for i, l := range links {
+		d := json.RawMessage(fmt.Sprintf(`"%d@order-%d"`, i*2, i))
+		l.Data = &d
+	}
+
+	u, _ := url.Parse(server.URL)
+	sim, _ := mock.NewAtomFeedSimulator(links, u, nil, -1)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Header.Get("ES-ResolveLinkTos"), Equals, "true")
+
+		m := eventPath.FindStringSubmatch(r.URL.Path)
+		if m == nil {
+			sim.ServeHTTP(w, r)
+			return
+		}
+
+		n, _ := strconv.Atoi(m[2])
+		for _, d := range deleted {
+			if d == n {
+				sim.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		data := json.RawMessage(fmt.Sprintf(`{"foo":"%d"}`, n))
+		e := mock.CreateTestEvent(fmt.Sprintf("order-%d", n), server.URL, "OrderPlaced", n*2, &data, nil)
+		er, _ := mock.CreateTestEventAtomResponse(e, nil)
+		fmt.Fprint(w, er.PrettyPrint())
+	})
+}
+
+func (s *LinksSuite) TestCategoryReaderResolvesLinks(c *C) {
+	setupLinkSimulator(c, "$ce-order", 5, 2)
+
+	stream := client.NewCategoryReader("order")
+	for i := 0; i < 5; i++ {
+		c.Assert(stream.Next(), Equals, true)
+		c.Assert(stream.Version(), Equals, i)
+
+		if i == 2 {
+			err, ok := stream.Err().(*goes.ErrUnresolvedLink)
+			c.Assert(ok, Equals, true)
+			c.Assert(err.Link, DeepEquals, goes.EventLink{StreamID: "$ce-order", EventNumber: 2})
+			c.Assert(err.TargetStreamID, Equals, "order-2")
+			c.Assert(err.TargetEventNumber, Equals, 4)
+			c.Assert(stream.EventResponse().Event.EventType, Equals, goes.LinkEventType)
+			continue
+		}
+
+		c.Assert(stream.Err(), IsNil)
+		e := stream.EventResponse()
+		c.Assert(e.Event.EventStreamID, Equals, fmt.Sprintf("order-%d", i))
+		c.Assert(e.Event.EventNumber, Equals, i*2)
+		c.Assert(e.Link, DeepEquals, &goes.EventLink{StreamID: "$ce-order", EventNumber: i})
+	}
+
+	stream.Next()
+	c.Assert(stream.Err(), DeepEquals, &goes.ErrNoMoreEvents{})
+}
+
+func (s *LinksSuite) TestEventTypeReaderResolvesLinks(c *C) {
+	setupLinkSimulator(c, "$et-OrderPlaced", 3)
+
+	stream := client.NewEventTypeReader("OrderPlaced")
+	for i := 0; i < 3; i++ {
+		stream.Next()
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.EventResponse().Event.EventType, Equals, "OrderPlaced")
+		c.Assert(stream.EventResponse().Link.StreamID, Equals, "$et-OrderPlaced")
+	}
+}
+
+// Tests that an unresolved link is delivered with the link event and that the
+// events after it are still delivered.
+func (s *LinksSuite) TestEventsDeliversUnresolvedLinks(c *C) {
+	setupLinkSimulator(c, "$ce-order", 5, 1, 3)
+
+	stream := client.NewCategoryReader("order")
+	var unresolved []int
+	count := 0
+	for r := range stream.Events(context.Background()) {
+		c.Assert(r.EventResponse, NotNil)
+		if err, ok := r.Err.(*goes.ErrUnresolvedLink); ok {
+			unresolved = append(unresolved, err.Link.EventNumber)
+		} else {
+			c.Assert(r.Err, IsNil)
+		}
+		count++
+	}
+	c.Assert(count, Equals, 5)
+	c.Assert(unresolved, DeepEquals, []int{1, 3})
+}
+
+func (s *LinksSuite) TestNewLinkEvent(c *C) {
+	e := goes.NewLinkEvent("order-1", 3)
+	c.Assert(e.EventType, Equals, goes.LinkEventType)
+	c.Assert(e.Data, Equals, "3@order-1")
+	c.Assert(e.EventID, Not(Equals), "")
+}
+
+func (s *LinksSuite) TestAppendLinkEvent(c *C) {
+	var got []map[string]interface{}
+	mux.HandleFunc("/streams/index", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(json.NewDecoder(r.Body).Decode(&got), IsNil)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	writer := client.NewStreamWriter("index")
+	err := writer.Append(goes.ExpectedVersion{}, goes.NewLinkEvent("order-1", 3))
+	c.Assert(err, IsNil)
+	c.Assert(got, HasLen, 1)
+	c.Assert(got[0]["eventType"], Equals, "$>")
+	c.Assert(got[0]["data"], Equals, "3@order-1")
+}
+
+func (s *LinksSuite) TestResolveLink(c *C) {
+	data := json.RawMessage(`{"foo":"bar"}`)
+	target := mock.CreateTestEvent("order-1", server.URL, "OrderPlaced", 3, &data, nil)
+	mux.HandleFunc("/streams/order-1/3", func(w http.ResponseWriter, r *http.Request) {
+		er, _ := mock.CreateTestEventAtomResponse(target, nil)
+		fmt.Fprint(w, er.PrettyPrint())
+	})
+
+	meta := json.RawMessage(`{"reason":"indexed"}`)
+	link := &goes.EventResponse{
+		Event: &goes.Event{
+			EventStreamID: "index",
+			EventNumber:   7,
+			EventType:     goes.LinkEventType,
+			Data:          "3@order-1",
+			MetaData:      &meta,
+		},
+	}
+
+	e, _, err := client.ResolveLink(link)
+	c.Assert(err, IsNil)
+	c.Assert(e.Event.EventStreamID, Equals, "order-1")
+	c.Assert(e.Event.EventNumber, Equals, 3)
+	c.Assert(e.Event.EventType, Equals, "OrderPlaced")
+	c.Assert(e.Link, DeepEquals, &goes.EventLink{StreamID: "index", EventNumber: 7, MetaData: &meta})
+}
+
+// Tests that links to events in deleted or truncated streams are reported
+// as unresolved links.
+func (s *LinksSuite) TestResolveLinkToDeletedEvent(c *C) {
+	mux.HandleFunc("/streams/deleted/3", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	mux.HandleFunc("/streams/truncated/3", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	for stream, want := range map[string]string{"deleted": "ErrDeleted", "truncated": "ErrNotFound"} {
+		link := &goes.EventResponse{
+			Event: &goes.Event{
+				EventStreamID: "index",
+				EventNumber:   7,
+				EventType:     goes.LinkEventType,
+				Data:          "3@" + stream,
+			},
+		}
+
+		_, _, err := client.ResolveLink(link)
+		e, ok := err.(*goes.ErrUnresolvedLink)
+		c.Assert(ok, Equals, true)
+		c.Assert(e.Link, DeepEquals, goes.EventLink{StreamID: "index", EventNumber: 7})
+		c.Assert(e.TargetStreamID, Equals, stream)
+		c.Assert(e.TargetEventNumber, Equals, 3)
+		c.Assert(reflect.TypeOf(e.Err).Elem().Name(), Equals, want)
+	}
+}
+
+func (s *LinksSuite) TestResolveLinkRequiresLinkEvent(c *C) {
+	_, _, err := client.ResolveLink(&goes.EventResponse{Event: &goes.Event{EventType: "OrderPlaced"}})
+	c.Assert(err, NotNil)
+}
+
+// Tests that the pages of a stream of links are read by position rather than by
+// the numbers of the events the links point to.
+func (s *LinksSuite) TestReadEventsFromLinkStream(c *C) {
+	mux.Handle("/", capPageSize(linkHandler(c, "$ce-order", 7), 2))
+
+	got, _, err := client.ReadEvents("$ce-order", 0, 5, "forward", goes.WithResolveLinkTos(true))
+	c.Assert(err, IsNil)
+	c.Assert(got, HasLen, 5)
+	for i, e := range got {
+		c.Assert(e.Event.EventStreamID, Equals, fmt.Sprintf("order-%d", i))
+		c.Assert(e.Event.EventNumber, Equals, i*2)
+	}
+
+	got, _, err = client.ReadEvents("$ce-order", -1, 5, "backward", goes.WithResolveLinkTos(true))
+	c.Assert(err, IsNil)
+	c.Assert(got, HasLen, 5)
+	for i, e := range got {
+		c.Assert(e.Event.EventStreamID, Equals, fmt.Sprintf("order-%d", 6-i))
+	}
+}
+
+// Tests that the position of a link is taken from the entry it was read from
+// when the positions of the link stream are not contiguous.
+func (s *LinksSuite) TestLinkPositionsWithGaps(c *C) {
+	pages := gapSimulator("$ce-order", []int{0, 3, 4, 8})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		m := eventPath.FindStringSubmatch(r.URL.Path)
+		if m == nil || m[1] != "$ce-order" {
+			pages.ServeHTTP(w, r)
+			return
+		}
+
+		n, _ := strconv.Atoi(m[2])
+		data := json.RawMessage(fmt.Sprintf(`{"foo":"%d"}`, n))
+		e := mock.CreateTestEvent(fmt.Sprintf("order-%d", n), server.URL, "OrderPlaced", n*2, &data, nil)
+		er, _ := mock.CreateTestEventAtomResponse(e, nil)
+		fmt.Fprint(w, er.PrettyPrint())
+	})
+
+	stream := client.NewCategoryReader("order")
+	for _, want := range []int{0, 3, 4, 8} {
+		c.Assert(stream.Next(), Equals, true)
+		c.Assert(stream.Err(), IsNil)
+		e := stream.EventResponse()
+		c.Assert(e.Event.EventStreamID, Equals, fmt.Sprintf("order-%d", want))
+		c.Assert(e.Link, DeepEquals, &goes.EventLink{StreamID: "$ce-order", EventNumber: want})
+	}
+
+	stream = client.NewCategoryReader("order")
+	stream.NextVersion(1)
+	c.Assert(stream.Next(), Equals, true)
+	c.Assert(stream.Err(), IsNil)
+	c.Assert(stream.EventResponse().Link, DeepEquals, &goes.EventLink{StreamID: "$ce-order", EventNumber: 3})
+}
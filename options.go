@@ -0,0 +1,114 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// RequestOption configures a single request to the eventstore.
+//
+// Options are applied after the headers and authentication set on the client so
+// an option always takes precedence over client wide configuration. Options
+// can be passed to methods on the Client and to StreamReaders and StreamWriters,
+// in which case they are applied to every request made by the reader or writer.
+type RequestOption func(req *http.Request)
+
+// WithHeader sets the header key to value on the request.
+func WithHeader(key, value string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set(key, value)
+	}
+}
+
+// WithoutHeader removes the header key from the request, for example to make a
+// request without a header that has been set on the client with SetHeader.
+func WithoutHeader(key string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Del(key)
+	}
+}
+
+// WithLongPoll causes the server to wait up to the number of seconds specified
+// for results to become available at the URL requested.
+//
+// Any value 0 or below will cause the request to be made without ES-LongPoll.
+func WithLongPoll(seconds int) RequestOption {
+	return func(req *http.Request) {
+		if seconds > 0 {
+			req.Header.Set("ES-LongPoll", strconv.Itoa(seconds))
+		} else {
+			req.Header.Del("ES-LongPoll")
+		}
+	}
+}
+
+// WithResolveLinkTos sets ES-ResolveLinkTos on the request.
+//
+// When true, which is the server default, link events are returned as the
+// events they point to.
+func WithResolveLinkTos(resolve bool) RequestOption {
+	return WithHeader("ES-ResolveLinkTos", strconv.FormatBool(resolve))
+}
+
+// WithRequiresMaster sets ES-RequiresMaster on the request.
+//
+// When true, the request will only be served by the master node of a cluster.
+func WithRequiresMaster(requiresMaster bool) RequestOption {
+	return WithHeader("ES-RequiresMaster", strconv.FormatBool(requiresMaster))
+}
+
+// WithHardDelete sets ES-HardDelete on the request.
+func WithHardDelete(hardDelete bool) RequestOption {
+	return func(req *http.Request) {
+		if hardDelete {
+			req.Header.Set("ES-HardDelete", "true")
+		} else {
+			req.Header.Del("ES-HardDelete")
+		}
+	}
+}
+
+// WithCredentials makes the request with the basic authentication credentials
+// provided rather than the credentials set on the client.
+func WithCredentials(username, password string) RequestOption {
+	return func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	}
+}
+
+// WithContext makes the request with the context provided.
+//
+// The request will be cancelled if the context is cancelled or its deadline
+// expires before the request completes.
+func WithContext(ctx context.Context) RequestOption {
+	return func(req *http.Request) {
+		*req = *req.WithContext(ctx)
+	}
+}
+
+// WithJSONFeed requests feed pages in the application/vnd.eventstore.atom+json
+// format which includes the event number, event type and, when used with
+// WithEmbed, the data and metadata of the events in the entries of the feed.
+func WithJSONFeed() RequestOption {
+	return WithHeader("Accept", "application/vnd.eventstore.atom+json")
+}
+
+// WithEmbed sets the embed parameter of the request which controls how much
+// of each event is included in the entries of a feed page.
+//
+// Valid modes are "content", "rich", "body", "pretty" and "tryharder". See the
+// eventstore documentation for the details of each mode. Event data and metadata
+// are embedded in feeds requested with WithJSONFeed.
+func WithEmbed(mode string) RequestOption {
+	return func(req *http.Request) {
+		q := req.URL.Query()
+		q.Set("embed", mode)
+		req.URL.RawQuery = q.Encode()
+	}
+}
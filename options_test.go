@@ -0,0 +1,198 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&OptionsSuite{})
+
+type OptionsSuite struct{}
+
+func (s *OptionsSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *OptionsSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+func (s *OptionsSuite) TestNewRequestAppliesOptions(c *C) {
+	req, err := client.NewRequest(http.MethodGet, "/streams/foo", nil,
+		goes.WithLongPoll(10),
+		goes.WithResolveLinkTos(false),
+		goes.WithRequiresMaster(true),
+		goes.WithHardDelete(true),
+		goes.WithHeader("X-Foo", "bar"))
+
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("ES-LongPoll"), Equals, "10")
+	c.Assert(req.Header.Get("ES-ResolveLinkTos"), Equals, "false")
+	c.Assert(req.Header.Get("ES-RequiresMaster"), Equals, "true")
+	c.Assert(req.Header.Get("ES-HardDelete"), Equals, "true")
+	c.Assert(req.Header.Get("X-Foo"), Equals, "bar")
+}
+
+func (s *OptionsSuite) TestOptionsTakePrecedenceOverClientConfiguration(c *C) {
+	client.SetHeader("ES-LongPoll", "15")
+	client.SetBasicAuth("user", "pass")
+
+	req, err := client.NewRequest(http.MethodGet, "/streams/foo", nil,
+		goes.WithLongPoll(0),
+		goes.WithCredentials("other", "secret"))
+
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("ES-LongPoll"), Equals, "")
+	username, password, ok := req.BasicAuth()
+	c.Assert(ok, Equals, true)
+	c.Assert(username, Equals, "other")
+	c.Assert(password, Equals, "secret")
+}
+
+// Tests that the options passed to a reader are used on requests for both the
+// feed pages and the events.
+func (s *OptionsSuite) TestStreamReaderAppliesOptionsToEveryRequest(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(3, streamName, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	sim, err := mock.NewAtomFeedSimulator(es, u, nil, -1)
+	c.Assert(err, IsNil)
+
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	requests := 0
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		c.Check(r.Header.Get("ES-ResolveLinkTos"), Equals, "true")
+		c.Check(r.Header.Get("Authorization"), Equals, auth)
+		sim.ServeHTTP(w, r)
+	})
+
+	reader := client.NewStreamReader(streamName,
+		goes.WithResolveLinkTos(true),
+		goes.WithCredentials("user", "pass"))
+
+	for i := 0; i < len(es); i++ {
+		c.Assert(reader.Next(), Equals, true)
+		c.Assert(reader.Err(), IsNil)
+	}
+	c.Assert(requests, Equals, len(es)+1)
+}
+
+// Tests that setting long poll on a reader does not leak the header into
+// other requests made with the same client.
+func (s *OptionsSuite) TestLongPollDoesNotChangeClientHeaders(c *C) {
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Header.Get("ES-LongPoll"), Equals, "15")
+		fmt.Fprint(w, "")
+	})
+
+	reader := client.NewStreamReader("SomeStream")
+	reader.LongPoll(15)
+	reader.Next()
+
+	req, err := client.NewRequest(http.MethodGet, "/streams/SomeStream", nil)
+	c.Assert(err, IsNil)
+	c.Assert(req.Header.Get("ES-LongPoll"), Equals, "")
+}
+
+// Tests that a reader can remove a header set on the client, either with an
+// option or by setting long poll on the reader.
+func (s *OptionsSuite) TestReaderOptionTakesPrecedenceOverClientHeader(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(2, streamName, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	sim, err := mock.NewAtomFeedSimulator(es, u, nil, -1)
+	c.Assert(err, IsNil)
+
+	requests := 0
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		c.Check(r.Header.Get("ES-LongPoll"), Equals, "")
+		sim.ServeHTTP(w, r)
+	})
+	client.SetHeader("ES-LongPoll", "15")
+
+	reader := client.NewStreamReader(streamName, goes.WithoutHeader("ES-LongPoll"))
+	for i := 0; i < len(es); i++ {
+		c.Assert(reader.Next(), Equals, true)
+		c.Assert(reader.Err(), IsNil)
+	}
+
+	reader = client.NewStreamReader(streamName)
+	reader.LongPoll(0)
+	for i := 0; i < len(es); i++ {
+		c.Assert(reader.Next(), Equals, true)
+		c.Assert(reader.Err(), IsNil)
+	}
+	c.Assert(requests, Equals, 2*(len(es)+1))
+}
+
+func (s *OptionsSuite) TestNextWithOptions(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(2, streamName, server.URL, "EventTypeX")
+	u, _ := url.Parse(server.URL)
+	sim, err := mock.NewAtomFeedSimulator(es, u, nil, -1)
+	c.Assert(err, IsNil)
+
+	var got []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("ES-RequiresMaster"))
+		sim.ServeHTTP(w, r)
+	})
+
+	reader := client.NewStreamReader(streamName, goes.WithRequiresMaster(false))
+	c.Assert(reader.NextWithOptions(goes.WithRequiresMaster(true)), Equals, true)
+	c.Assert(reader.Err(), IsNil)
+	c.Assert(reader.Next(), Equals, true)
+	c.Assert(reader.Err(), IsNil)
+
+	// The feed page and first event are read with the option passed to
+	// NextWithOptions and the second event with the reader's option.
+	c.Assert(got, DeepEquals, []string{"true", "true", "false"})
+}
+
+func (s *OptionsSuite) TestAppendWithOptions(c *C) {
+	mux.HandleFunc("/streams/SomeStream", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("ES-RequiresMaster"), Equals, "true")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	writer := client.NewStreamWriter("SomeStream", goes.WithRequiresMaster(false))
+	events := []*goes.Event{goes.NewEvent("", "FooEvent", &FooEvent{Foo: "bar"}, nil)}
+	err := writer.AppendWithOptions(goes.ExpectedVersion{}, events, goes.WithRequiresMaster(true))
+	c.Assert(err, IsNil)
+}
+
+func (s *OptionsSuite) TestStreamWriterAppliesOptions(c *C) {
+	mux.HandleFunc("/streams/SomeStream", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("ES-RequiresMaster"), Equals, "true")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	writer := client.NewStreamWriter("SomeStream", goes.WithRequiresMaster(true))
+	err := writer.Append(goes.ExpectedVersion{}, goes.NewEvent("", "FooEvent", &FooEvent{Foo: "bar"}, nil))
+	c.Assert(err, IsNil)
+}
+
+func (s *OptionsSuite) TestDeleteStreamAppliesOptions(c *C) {
+	mux.HandleFunc("/streams/SomeStream", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodDelete)
+		c.Assert(r.Header.Get("ES-HardDelete"), Equals, "true")
+		c.Assert(r.Header.Get("ES-RequiresMaster"), Equals, "true")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	resp, err := client.DeleteStream("SomeStream", true, goes.WithRequiresMaster(true))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusNoContent)
+}
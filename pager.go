@@ -0,0 +1,203 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/jetbasrawi/go.geteventstore/atom"
+)
+
+// FeedPager navigates the atom feed pages of a stream.
+//
+// GetEventStore uses next to point to older feed pages and previous to point
+// to more recent feed pages. The first page is the page at the head of the
+// stream and the last page is the page containing the first event of the
+// stream. A stream can be paged forward from the oldest events by calling Last
+// followed by Previous, or backward from the head by calling First followed by
+// Next.
+//
+// Pages are requested with If-None-Match when a page is read again so that
+// a page that has not changed, such as the head page of a stream with no new
+// events, is returned quickly with 304 Not Modified.
+type FeedPager struct {
+	client     *Client
+	streamName string
+	opts       []RequestOption
+	url        string
+	feed       *atom.Feed
+	etag       string
+	rel        string
+	visited    map[string]bool
+}
+
+// NewFeedPager returns a new *FeedPager for the stream.
+//
+// Any options provided will be applied to every request made by the pager.
+func (c *Client) NewFeedPager(streamName string, opts ...RequestOption) *FeedPager {
+	return &FeedPager{
+		client:     c,
+		streamName: streamName,
+		opts:       opts,
+	}
+}
+
+// Feed returns the current feed page. Feed returns nil until a page has been
+// read.
+func (p *FeedPager) Feed() *atom.Feed {
+	return p.feed
+}
+
+// URL returns the url of the current feed page.
+func (p *FeedPager) URL() string {
+	return p.url
+}
+
+// HeadOfStream reports whether the current feed page is at the head of the
+// stream.
+func (p *FeedPager) HeadOfStream() bool {
+	return p.feed != nil && p.feed.HeadOfStream
+}
+
+// Load reads the feed page at url and makes it the current page.
+func (p *FeedPager) Load(url string) (*atom.Feed, error) {
+	p.rel = ""
+	if _, err := p.load(url); err != nil {
+		return nil, err
+	}
+	return p.feed, nil
+}
+
+// First reads the first feed page, which is the page at the head of the stream.
+func (p *FeedPager) First() (*atom.Feed, error) {
+	if p.feed == nil {
+		url, err := p.client.GetFeedPath(p.streamName, "backward", -1, DefaultPageSize)
+		if err != nil {
+			return nil, err
+		}
+		return p.Load(url)
+	}
+	return p.follow("first")
+}
+
+// Last reads the last feed page, which is the page containing the oldest events
+// of the stream.
+func (p *FeedPager) Last() (*atom.Feed, error) {
+	if p.feed == nil {
+		url, err := p.client.GetFeedPath(p.streamName, "forward", 0, DefaultPageSize)
+		if err != nil {
+			return nil, err
+		}
+		return p.Load(url)
+	}
+	return p.follow("last")
+}
+
+// Next reads the next feed page, which contains older events than the current
+// page.
+//
+// An *ErrNoMoreEvents is returned if the current page is the last page.
+func (p *FeedPager) Next() (*atom.Feed, error) {
+	return p.follow("next")
+}
+
+// Previous reads the previous feed page, which contains more recent events than
+// the current page.
+//
+// An *ErrNoMoreEvents is returned if the current page is at the head of the
+// stream. Use Refresh to read the current page again to find out whether more
+// events have been written.
+func (p *FeedPager) Previous() (*atom.Feed, error) {
+	if p.HeadOfStream() {
+		return nil, &ErrNoMoreEvents{}
+	}
+	return p.follow("previous")
+}
+
+// Refresh reads the current feed page again.
+//
+// modified is false if the server returned 304 Not Modified, in which case the
+// current page is returned.
+func (p *FeedPager) Refresh() (feed *atom.Feed, modified bool, err error) {
+	if p.feed == nil {
+		return nil, false, &ErrNoMoreEvents{}
+	}
+	modified, err = p.load(p.url)
+	if err != nil {
+		return nil, false, err
+	}
+	return p.feed, modified, nil
+}
+
+// follow reads the feed page at the link of the current page with the relation
+// rel.
+//
+// An *ErrFeedLoop is returned if following the same relation repeatedly leads
+// back to a page that has already been read.
+func (p *FeedPager) follow(rel string) (*atom.Feed, error) {
+	l := p.feed.GetLink(rel)
+	if l == nil {
+		return nil, &ErrNoMoreEvents{}
+	}
+
+	u, err := p.resolve(l.Href)
+	if err != nil {
+		return nil, err
+	}
+
+	if rel != p.rel {
+		p.rel = rel
+		p.visited = map[string]bool{p.url: true}
+	}
+	if p.visited[u] {
+		return nil, &ErrFeedLoop{URL: u}
+	}
+
+	if _, err := p.load(u); err != nil {
+		return nil, err
+	}
+	p.visited[u] = true
+	return p.feed, nil
+}
+
+// load reads the feed page at url and makes it the current page. If url is the
+// url of the current page the request is made conditional on the page having
+// changed. modified is false if the page has not changed.
+func (p *FeedPager) load(u string) (modified bool, err error) {
+	u, err = p.resolve(u)
+	if err != nil {
+		return false, err
+	}
+
+	opts := p.opts
+	if u == p.url && p.etag != "" {
+		opts = append(append([]RequestOption{}, p.opts...), WithHeader("If-None-Match", p.etag))
+	}
+
+	f, resp, err := p.client.ReadFeed(u, opts...)
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+
+	p.url = u
+	p.feed = f
+	p.etag = resp.Header.Get("ETag")
+	return true, nil
+}
+
+// resolve makes u absolute so that relative and absolute urls of the same page
+// are recognised as the same page.
+func (p *FeedPager) resolve(u string) (string, error) {
+	ref, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	return p.client.baseURL.ResolveReference(ref).String(), nil
+}
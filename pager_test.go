@@ -0,0 +1,175 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	"github.com/jetbasrawi/go.geteventstore/atom"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&FeedPagerSuite{})
+
+type FeedPagerSuite struct{}
+
+func (s *FeedPagerSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *FeedPagerSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+// entryRange returns the event numbers of the newest and oldest entries of
+// the feed page.
+func entryRange(f *atom.Feed) (string, string) {
+	return f.Entry[0].Title, f.Entry[len(f.Entry)-1].Title
+}
+
+func (s *FeedPagerSuite) TestPageBackwardFromHead(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(45, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	pager := client.NewFeedPager(streamName)
+	f, err := pager.First()
+	c.Assert(err, IsNil)
+	c.Assert(pager.HeadOfStream(), Equals, true)
+	newest, oldest := entryRange(f)
+	c.Assert(newest, Equals, "44@SomeStream")
+	c.Assert(oldest, Equals, "25@SomeStream")
+
+	f, err = pager.Next()
+	c.Assert(err, IsNil)
+	c.Assert(pager.HeadOfStream(), Equals, false)
+	newest, oldest = entryRange(f)
+	c.Assert(newest, Equals, "24@SomeStream")
+	c.Assert(oldest, Equals, "5@SomeStream")
+
+	f, err = pager.Next()
+	c.Assert(err, IsNil)
+	newest, oldest = entryRange(f)
+	c.Assert(newest, Equals, "4@SomeStream")
+	c.Assert(oldest, Equals, "0@SomeStream")
+
+	_, err = pager.Next()
+	c.Assert(err, DeepEquals, &goes.ErrNoMoreEvents{})
+	c.Assert(pager.Feed(), Equals, f)
+}
+
+func (s *FeedPagerSuite) TestPageForwardFromLast(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(45, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	pager := client.NewFeedPager(streamName)
+	f, err := pager.Last()
+	c.Assert(err, IsNil)
+	newest, oldest := entryRange(f)
+	c.Assert(newest, Equals, "19@SomeStream")
+	c.Assert(oldest, Equals, "0@SomeStream")
+
+	f, err = pager.Previous()
+	c.Assert(err, IsNil)
+	newest, oldest = entryRange(f)
+	c.Assert(newest, Equals, "39@SomeStream")
+	c.Assert(oldest, Equals, "20@SomeStream")
+
+	f, err = pager.Previous()
+	c.Assert(err, IsNil)
+	c.Assert(pager.HeadOfStream(), Equals, true)
+	newest, oldest = entryRange(f)
+	c.Assert(newest, Equals, "44@SomeStream")
+	c.Assert(oldest, Equals, "40@SomeStream")
+
+	_, err = pager.Previous()
+	c.Assert(err, DeepEquals, &goes.ErrNoMoreEvents{})
+
+	// The first and last relations can be followed from any page.
+	f, err = pager.Last()
+	c.Assert(err, IsNil)
+	c.Assert(pager.URL(), Equals, server.URL+"/streams/SomeStream/0/forward/20")
+	f, err = pager.First()
+	c.Assert(err, IsNil)
+	c.Assert(pager.URL(), Equals, server.URL+"/streams/SomeStream/head/backward/20")
+}
+
+func (s *FeedPagerSuite) TestFeedLoopIsDetected(c *C) {
+	es := mock.CreateTestEvents(2, "SomeStream", server.URL, "FooEvent")
+	pages := map[string]string{
+		"/streams/SomeStream/a": "/streams/SomeStream/b",
+		"/streams/SomeStream/b": "/streams/SomeStream/a",
+	}
+	for path, next := range pages {
+		f, _ := mock.CreateTestFeed(es, server.URL+path)
+		f.Link = append(f.Link, atom.Link{Rel: "next", Href: server.URL + next})
+		body := f.PrettyPrint()
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		})
+	}
+
+	pager := client.NewFeedPager("SomeStream")
+	_, err := pager.Load("/streams/SomeStream/a")
+	c.Assert(err, IsNil)
+	_, err = pager.Next()
+	c.Assert(err, IsNil)
+	_, err = pager.Next()
+	c.Assert(err, DeepEquals, &goes.ErrFeedLoop{URL: server.URL + "/streams/SomeStream/a"})
+}
+
+// Tests that the current page is read again with If-None-Match and that an
+// unchanged page is returned when the server returns 304 Not Modified.
+func (s *FeedPagerSuite) TestRefreshIsConditional(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(3, streamName, server.URL, "FooEvent")
+	path := "/streams/SomeStream/head/backward/20"
+	f, _ := mock.CreateTestFeed(es, server.URL+path)
+
+	etag := `"2;-1296467268"`
+	requests := 0
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, f.PrettyPrint())
+	})
+
+	pager := client.NewFeedPager(streamName)
+	first, err := pager.First()
+	c.Assert(err, IsNil)
+
+	got, modified, err := pager.Refresh()
+	c.Assert(err, IsNil)
+	c.Assert(modified, Equals, false)
+	c.Assert(got, Equals, first)
+	c.Assert(requests, Equals, 2)
+
+	// Once the page changes it is returned.
+	etag = `"3;-1296467268"`
+	got, modified, err = pager.Refresh()
+	c.Assert(err, IsNil)
+	c.Assert(modified, Equals, true)
+	c.Assert(got, Not(Equals), first)
+}
+
+func (s *FeedPagerSuite) TestReadFeedNotModified(c *C) {
+	mux.HandleFunc("/streams/SomeStream", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("If-None-Match"), Equals, `"1;2"`)
+		w.WriteHeader(http.StatusNotModified)
+	})
+
+	f, resp, err := client.ReadFeed("/streams/SomeStream", goes.WithHeader("If-None-Match", `"1;2"`))
+	c.Assert(err, IsNil)
+	c.Assert(f, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusNotModified)
+}
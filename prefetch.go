@@ -0,0 +1,128 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import "context"
+
+// prefetcher reads events ahead of the consumer of a StreamReader.
+//
+// The prefetcher runs a copy of the reader in a background goroutine. The
+// position of the copy after each read is sent on results, which is closed when
+// the background goroutine exits.
+type prefetcher struct {
+	results chan prefetchResult
+	cancel  context.CancelFunc
+}
+
+// prefetchResult is the outcome of a single read by the prefetcher.
+type prefetchResult struct {
+	pos readerPosition
+	ok  bool
+}
+
+// Prefetch sets the number of events that the reader will read ahead of the
+// consumer.
+//
+// When depth is greater than 0, feed pages and events are read in a background
+// goroutine while the consumer processes the events already returned by Next.
+// Events are returned by Next in stream order and errors are returned at the
+// position in the stream at which they occurred, exactly as they would be
+// without prefetching. The background goroutine stops after reading an error,
+// including ErrNoMoreEvents, and is restarted on the following call to Next.
+//
+// A depth of 0 or less disables prefetching, which is the default.
+//
+// Close should be called when a reader that is prefetching is no longer needed.
+func (s *StreamReader) Prefetch(depth int) {
+	s.stopPrefetch()
+	s.prefetchDepth = depth
+}
+
+// Close stops any events being read in the background by the reader.
+//
+// Events that have been read ahead but not yet returned by Next are discarded
+// and the reader can continue to be used from its current position.
+func (s *StreamReader) Close() {
+	s.stopPrefetch()
+}
+
+func (s *StreamReader) nextPrefetched() bool {
+	if s.prefetcher == nil {
+		s.startPrefetch()
+	}
+
+	r, open := <-s.prefetcher.results
+	if !open {
+		// The background reader was cancelled through the reader's context
+		// before the next event was read.
+		s.stopPrefetch()
+		s.eventResponse = nil
+		s.lasterr = context.Canceled
+		if s.ctx != nil && s.ctx.Err() != nil {
+			s.lasterr = s.ctx.Err()
+		}
+		return true
+	}
+
+	s.seek(r.pos)
+	if s.lasterr != nil {
+		s.stopPrefetch()
+	}
+	return r.ok
+}
+
+func (s *StreamReader) startPrefetch() {
+	parent := s.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	p := &prefetcher{
+		results: make(chan prefetchResult, s.prefetchDepth),
+		cancel:  cancel,
+	}
+
+	r := *s
+	r.ctx = ctx
+	r.prefetchDepth = 0
+	r.prefetcher = nil
+
+	go func() {
+		defer close(p.results)
+
+		for {
+			ok := r.next()
+			if ctx.Err() != nil {
+				return
+			}
+
+			select {
+			case p.results <- prefetchResult{pos: r.position(), ok: ok}:
+			case <-ctx.Done():
+				return
+			}
+
+			if r.lasterr != nil {
+				return
+			}
+		}
+	}()
+
+	s.prefetcher = p
+}
+
+// stopPrefetch stops the background reader and discards any events it has read.
+func (s *StreamReader) stopPrefetch() {
+	if s.prefetcher == nil {
+		return
+	}
+
+	s.prefetcher.cancel()
+	for range s.prefetcher.results {
+	}
+	s.prefetcher = nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&PrefetchSuite{})
+
+type PrefetchSuite struct{}
+
+func (s *PrefetchSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *PrefetchSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+// Tests that events are returned in order and that the end of the stream is
+// reported at the same position as without prefetching.
+func (s *PrefetchSuite) TestPrefetchPreservesOrder(c *C) {
+	streamName := "SomeStream"
+	ne := 45
+	es := mock.CreateTestEvents(ne, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	reader := client.NewStreamReader(streamName)
+	reader.Prefetch(5)
+	defer reader.Close()
+
+	for i := 0; i < ne; i++ {
+		c.Assert(reader.Next(), Equals, true)
+		c.Assert(reader.Err(), IsNil)
+		c.Assert(reader.Version(), Equals, i)
+		c.Assert(reader.EventResponse().Event.EventNumber, Equals, i)
+		c.Assert(reader.EventResponse().Event.EventID, Equals, es[i].EventID)
+	}
+
+	c.Assert(reader.Next(), Equals, true)
+	c.Assert(reader.Err(), DeepEquals, &goes.ErrNoMoreEvents{})
+	c.Assert(reader.EventResponse(), IsNil)
+}
+
+// Tests that events are read ahead of the consumer.
+func (s *PrefetchSuite) TestPrefetchReadsAhead(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(10, streamName, server.URL, "FooEvent")
+	u, _ := url.Parse(server.URL)
+	sim, _ := mock.NewAtomFeedSimulator(es, u, nil, -1)
+
+	var mu sync.Mutex
+	requests := 0
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		sim.ServeHTTP(w, r)
+	})
+
+	reader := client.NewStreamReader(streamName)
+	reader.Prefetch(3)
+	defer reader.Close()
+
+	c.Assert(reader.Next(), Equals, true)
+	c.Assert(reader.Err(), IsNil)
+
+	// One feed page, the event returned, three events buffered and one event
+	// waiting to be buffered.
+	want := 6
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		got := requests
+		mu.Unlock()
+		if got >= want || time.Now().After(deadline) {
+			c.Assert(got, Equals, want)
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Tests that an error is returned at the position it occurred and that the
+// reader continues from that position once the error has cleared.
+func (s *PrefetchSuite) TestPrefetchPreservesErrorPosition(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(10, streamName, server.URL, "FooEvent")
+	u, _ := url.Parse(server.URL)
+	sim, _ := mock.NewAtomFeedSimulator(es, u, nil, -1)
+
+	var mu sync.Mutex
+	fail := true
+	failPath := fmt.Sprintf("/streams/%s/7", streamName)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		f := fail
+		mu.Unlock()
+		if f && r.URL.Path == failPath {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		sim.ServeHTTP(w, r)
+	})
+
+	reader := client.NewStreamReader(streamName)
+	reader.Prefetch(4)
+	defer reader.Close()
+
+	for i := 0; i < 7; i++ {
+		c.Assert(reader.Next(), Equals, true)
+		c.Assert(reader.Err(), IsNil)
+		c.Assert(reader.EventResponse().Event.EventNumber, Equals, i)
+	}
+
+	c.Assert(reader.Next(), Equals, true)
+	c.Assert(reflect.TypeOf(reader.Err()).Elem().Name(), Equals, "ErrTemporarilyUnavailable")
+	c.Assert(reader.Version(), Equals, 6)
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+
+	for i := 7; i < 10; i++ {
+		c.Assert(reader.Next(), Equals, true)
+		c.Assert(reader.Err(), IsNil)
+		c.Assert(reader.EventResponse().Event.EventNumber, Equals, i)
+	}
+}
+
+// Tests that the prefetched events are discarded when the reader is moved to
+// another version.
+func (s *PrefetchSuite) TestPrefetchWithNextVersion(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(25, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	reader := client.NewStreamReader(streamName)
+	reader.Prefetch(5)
+	defer reader.Close()
+
+	reader.NextVersion(9)
+	c.Assert(reader.Next(), Equals, true)
+	c.Assert(reader.Err(), IsNil)
+	c.Assert(reader.EventResponse().Event.EventNumber, Equals, 9)
+	c.Assert(reader.Next(), Equals, true)
+	c.Assert(reader.EventResponse().Event.EventNumber, Equals, 10)
+}
+
+// Tests that events streamed over a channel are prefetched.
+func (s *PrefetchSuite) TestPrefetchWithEvents(c *C) {
+	streamName := "SomeStream"
+	ne := 30
+	es := mock.CreateTestEvents(ne, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	reader := client.NewStreamReader(streamName)
+	reader.Prefetch(5)
+	defer reader.Close()
+
+	count := 0
+	for r := range reader.Events(context.Background()) {
+		c.Assert(r.Err, IsNil)
+		c.Assert(r.EventResponse.Event.EventNumber, Equals, count)
+		count++
+	}
+	c.Assert(count, Equals, ne)
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// maxDrainSize is the number of bytes that are read from the unread remainder
+// of a response body before it is closed when no maximum response size is set.
+const maxDrainSize = 256 << 10
+
+// SetMaxResponseSize sets the maximum number of bytes that will be read from
+// the body of a response after it has been decompressed. Reading a larger
+// response returns an *ErrResponseTooLarge.
+//
+// Feed pages of MaxPageSize entries with embedded event data can be large, the
+// limit protects the client from responses that would exhaust its memory.
+// A size of 0 or less removes the limit, which is the default.
+func (c *Client) SetMaxResponseSize(size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxResponseSize = size
+}
+
+// limitResponse replaces the body of the response with a reader that returns an
+// *ErrResponseTooLarge if the body is larger than the maximum response size.
+func (c *Client) limitResponse(resp *http.Response) {
+	c.mu.RLock()
+	limit := c.maxResponseSize
+	c.mu.RUnlock()
+
+	if limit > 0 {
+		resp.Body = &limitedReader{r: resp.Body, limit: limit, remaining: limit}
+	}
+}
+
+// closeBody reads what remains of the body of a response before closing it so
+// that the connection can be reused for another request.
+//
+// No more than the maximum response size is read. The connection of a body
+// with more remaining than that is closed with the body.
+func (c *Client) closeBody(body io.ReadCloser) error {
+	c.mu.RLock()
+	limit := c.maxResponseSize
+	c.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = maxDrainSize
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(body, limit))
+	return body.Close()
+}
+
+// limitedReader reads from r until more than limit bytes have been read.
+//
+// Unlike io.LimitedReader, reaching the limit is an error rather than the end of
+// the body so that a truncated response is never decoded successfully.
+type limitedReader struct {
+	r         io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, &ErrResponseTooLarge{Limit: l.limit}
+	}
+	// Read one byte more than remains so that a body of exactly the limit
+	// is not reported as too large.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), &ErrResponseTooLarge{Limit: l.limit}
+	}
+	return n, err
+}
+
+func (l *limitedReader) Close() error {
+	return l.r.Close()
+}
@@ -0,0 +1,273 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	"github.com/jetbasrawi/go.geteventstore/atom"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&ResponseSuite{})
+
+type ResponseSuite struct{}
+
+func (s *ResponseSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *ResponseSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+// handleFeed serves a feed page of n events at path and returns the body of
+// the page.
+func handleFeed(n int, path string) string {
+	es := mock.CreateTestEvents(n, "SomeStream", server.URL, "FooEvent")
+	f, _ := mock.CreateTestFeed(es, server.URL+path)
+	body := f.PrettyPrint()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		fmt.Fprint(w, body)
+	})
+	return body
+}
+
+func (s *ResponseSuite) TestDoStreamReturnsOpenBody(c *C) {
+	path := "/streams/SomeStream/head/backward/20"
+	body := handleFeed(5, path)
+
+	req, err := client.NewRequest("GET", path, nil)
+	c.Assert(err, IsNil)
+	resp, err := client.DoStream(req)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+
+	got, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(got), Equals, body)
+}
+
+func (s *ResponseSuite) TestDoStreamClosesBodyOnError(c *C) {
+	mux.HandleFunc("/streams/SomeStream", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+	})
+
+	req, err := client.NewRequest("GET", "/streams/SomeStream", nil)
+	c.Assert(err, IsNil)
+	resp, err := client.DoStream(req)
+	c.Assert(err, FitsTypeOf, &goes.ErrNotFound{})
+	c.Assert(resp.StatusCode, Equals, http.StatusNotFound)
+}
+
+func (s *ResponseSuite) TestMaxResponseSize(c *C) {
+	path := "/streams/SomeStream/head/backward/20"
+	body := handleFeed(20, path)
+
+	client.SetMaxResponseSize(int64(len(body)))
+	f, _, err := client.ReadFeed(path)
+	c.Assert(err, IsNil)
+	c.Assert(f.Entry, HasLen, 20)
+
+	client.SetMaxResponseSize(int64(len(body) - 1))
+	f, _, err = client.ReadFeed(path)
+	c.Assert(err, DeepEquals, &goes.ErrResponseTooLarge{Limit: int64(len(body) - 1)})
+	c.Assert(f, IsNil)
+
+	client.SetMaxResponseSize(0)
+	_, _, err = client.ReadFeed(path)
+	c.Assert(err, IsNil)
+}
+
+func (s *ResponseSuite) TestMaxResponseSizeGetEvent(c *C) {
+	es := mock.CreateTestEvents(1, "SomeStream", server.URL, "FooEvent")
+	er, _ := mock.CreateTestEventAtomResponse(es[0], nil)
+	body := er.PrettyPrint()
+	mux.HandleFunc("/streams/SomeStream/0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	})
+
+	client.SetMaxResponseSize(int64(len(body) / 2))
+	ev, _, err := client.GetEvent("/streams/SomeStream/0")
+	c.Assert(err, DeepEquals, &goes.ErrResponseTooLarge{Limit: int64(len(body) / 2)})
+	c.Assert(ev, IsNil)
+}
+
+// The benchmarks compare the allocations made decoding responses as they are
+// read from the response body with decoding them after buffering the body.
+// Run them with go test -run NONE -bench .
+
+// benchmarkFeedSize is the number of entries of the feed page read by the
+// benchmarks.
+const benchmarkFeedSize = 4096
+
+// handleLargeEvent serves an event with benchmarkFeedSize fields of data at
+// path.
+func handleLargeEvent(path string) {
+	fields := make([]string, benchmarkFeedSize)
+	for i := range fields {
+		fields[i] = fmt.Sprintf("\"field%d\":\"%s\"", i, strings.Repeat("x", 64))
+	}
+	data := json.RawMessage("{" + strings.Join(fields, ",") + "}")
+	e := mock.CreateTestEvent("SomeStream", server.URL, "FooEvent", 0, &data, nil)
+	er, _ := mock.CreateTestEventAtomResponse(e, nil)
+	body := er.PrettyPrint()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	})
+}
+
+// doBuffered reads the response to a request for path into a buffer.
+func doBuffered(b *testing.B, path, accept string) []byte {
+	req, err := client.NewRequest("GET", path, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	req.Header.Set("Accept", accept)
+
+	var buf bytes.Buffer
+	if _, err := client.Do(req, &buf); err != nil {
+		b.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkReadFeedStreaming(b *testing.B) {
+	setup()
+	defer teardown()
+	path := "/streams/SomeStream/head/backward/4096"
+	handleFeed(benchmarkFeedSize, path)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := client.ReadFeed(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadFeedBuffered(b *testing.B) {
+	setup()
+	defer teardown()
+	path := "/streams/SomeStream/head/backward/4096"
+	handleFeed(benchmarkFeedSize, path)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		body := doBuffered(b, path, "application/atom+xml")
+		if err := xml.Unmarshal(body, &atom.Feed{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetEventStreaming(b *testing.B) {
+	setup()
+	defer teardown()
+	path := "/streams/SomeStream/0"
+	handleLargeEvent(path)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := client.GetEvent(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetEventBuffered(b *testing.B) {
+	setup()
+	defer teardown()
+	path := "/streams/SomeStream/0"
+	handleLargeEvent(path)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		body := doBuffered(b, path, "application/vnd.eventstore.atom+json")
+		var d, m json.RawMessage
+		er := &goes.EventAtomResponse{Content: &goes.Event{Data: &d, MetaData: &m}}
+		if err := json.Unmarshal(body, er); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDo(b *testing.B) {
+	setup()
+	defer teardown()
+	path := "/streams/SomeStream/head/backward/4096"
+	handleFeed(benchmarkFeedSize, path)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		doBuffered(b, path, "application/atom+xml")
+	}
+}
+
+func BenchmarkDoStream(b *testing.B) {
+	setup()
+	defer teardown()
+	path := "/streams/SomeStream/head/backward/4096"
+	handleFeed(benchmarkFeedSize, path)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req, err := client.NewRequest("GET", path, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		resp, err := client.DoStream(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Tests that the connection of a response whose body was not read is reused
+// for the next request when the body is within the maximum response size.
+func (s *ResponseSuite) TestUnreadBodiesAreDrained(c *C) {
+	body := strings.Repeat("x", 512<<10)
+	client.SetMaxResponseSize(1 << 20)
+	mux.HandleFunc("/streams/SomeStream", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	})
+
+	var reused []bool
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) { reused = append(reused, info.Reused) },
+	}
+	ctx := httptrace.WithClientTrace(context.Background(), trace)
+	for i := 0; i < 2; i++ {
+		req, err := client.NewRequest("GET", "/streams/SomeStream", nil, goes.WithContext(ctx))
+		c.Assert(err, IsNil)
+		_, err = client.Do(req, nil)
+		c.Assert(err, IsNil)
+	}
+	c.Assert(reused, DeepEquals, []bool{false, true})
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DecideFunc returns the events to append to a stream given the events of the
+// stream and its current version, which is -1 if the stream does not exist.
+//
+// Returning no events completes WithOptimisticRetry without writing to the
+// stream and returning an error aborts it.
+type DecideFunc func(currentVersion int, events []*EventResponse) ([]*Event, error)
+
+// WithOptimisticRetry reads the events of a stream, passes them to decide and
+// appends the events it returns with the version of the stream as the expected
+// version.
+//
+// If another writer appends to the stream in the meantime, the events written
+// since the stream was last read are read and decide is called again with all of
+// the events of the stream. This is repeated up to maxAttempts times after which
+// the last *ErrConcurrencyViolation is returned.
+//
+// decide may be called more than once so it should not have side effects other
+// than returning events. If the stream cannot be read up to the current version
+// reported with a conflict, for example because it is read from a node that is
+// behind, an error is returned rather than calling decide with stale events.
+func (c *Client) WithOptimisticRetry(streamName string, maxAttempts int, decide DecideFunc) error {
+	if maxAttempts < 1 {
+		return fmt.Errorf("Invalid number of attempts %d.", maxAttempts)
+	}
+
+	writer := c.NewStreamWriter(streamName)
+
+	var history []*EventResponse
+	version, current := -1, -1
+	var err error
+	for attempt := 1; ; attempt++ {
+		history, version, err = c.readFrom(streamName, history, version)
+		if err != nil {
+			return err
+		}
+		if version < current {
+			return fmt.Errorf("The stream %s was read to version %d which is before its current version %d.", streamName, version, current)
+		}
+
+		events, err := decide(version, history)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		expectedVersion := NoStream()
+		if version >= 0 {
+			expectedVersion = Exact(version)
+		}
+		err = writer.Append(expectedVersion, events...)
+		var violation *ErrConcurrencyViolation
+		if !errors.As(err, &violation) || attempt == maxAttempts {
+			return err
+		}
+		if violation.HasCurrentVersion {
+			current = violation.CurrentVersion
+		}
+	}
+}
+
+// readFrom reads the events of the stream after version and appends them to
+// history. It returns the events and the version of the last event read, which
+// is -1 if the stream does not exist.
+func (c *Client) readFrom(streamName string, history []*EventResponse, version int) ([]*EventResponse, int, error) {
+	// The reader must not wait for new events at the end of the stream if the
+	// client sets ES-LongPoll.
+	reader := c.NewStreamReader(streamName)
+	reader.LongPoll(0)
+	reader.NextVersion(version + 1)
+	for reader.Next() {
+		switch err := reader.Err().(type) {
+		case nil:
+			history = append(history, reader.EventResponse())
+			version = reader.Version()
+		case *ErrNoMoreEvents, *ErrNotFound:
+			return history, version, nil
+		default:
+			return nil, -1, err
+		}
+	}
+	return history, version, nil
+}
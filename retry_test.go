@@ -0,0 +1,248 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&RetrySuite{})
+
+type RetrySuite struct{}
+
+func (s *RetrySuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *RetrySuite) TearDownTest(c *C) {
+	teardown()
+}
+
+// writableStream simulates a stream that can be read and appended to. Appends
+// with an expected version that does not match the version of the stream are
+// rejected in the same way as by the eventstore.
+type writableStream struct {
+	c       *C
+	mu      sync.Mutex
+	name    string
+	count   int
+	handler http.Handler
+	reads   map[int]int
+	appends int
+
+	// lag is the number of the latest events that are not yet readable.
+	lag int
+
+	// longPolls counts the reads made with ES-LongPoll set.
+	longPolls int
+
+	// beforeAppend is called before an append is handled.
+	beforeAppend func()
+}
+
+func newWritableStream(c *C, name string, count int) *writableStream {
+	ws := &writableStream{c: c, name: name, reads: map[int]int{}}
+	ws.write(count)
+	mux.Handle("/", ws)
+	return ws
+}
+
+// write adds n events to the stream.
+func (ws *writableStream) write(n int) {
+	ws.count += n
+	if ws.count-ws.lag == 0 {
+		ws.handler = http.NotFoundHandler()
+		return
+	}
+	u, _ := url.Parse(server.URL)
+	es := mock.CreateTestEvents(ws.count-ws.lag, ws.name, server.URL, "FooEvent")
+	h, err := mock.NewAtomFeedSimulator(es, u, nil, -1)
+	ws.c.Assert(err, IsNil)
+	ws.handler = h
+}
+
+func (ws *writableStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		ws.append(w, r)
+		return
+	}
+
+	ws.mu.Lock()
+	if r.Header.Get("ES-LongPoll") != "" {
+		ws.longPolls++
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if n, err := strconv.Atoi(parts[len(parts)-1]); err == nil && len(parts) == 3 {
+		ws.reads[n]++
+	}
+	h := ws.handler
+	ws.mu.Unlock()
+	h.ServeHTTP(w, r)
+}
+
+func (ws *writableStream) append(w http.ResponseWriter, r *http.Request) {
+	if ws.beforeAppend != nil {
+		ws.beforeAppend()
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.appends++
+
+	var events []*goes.Event
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	expected, err := strconv.Atoi(r.Header.Get("ES-ExpectedVersion"))
+	if err != nil || expected != ws.count-1 {
+		w.Header().Set("ES-CurrentVersion", strconv.Itoa(ws.count-1))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ws.write(len(events))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *RetrySuite) TestWithOptimisticRetryAppends(c *C) {
+	ws := newWritableStream(c, "SomeStream", 3)
+
+	err := client.WithOptimisticRetry("SomeStream", 3, func(version int, events []*goes.EventResponse) ([]*goes.Event, error) {
+		c.Assert(version, Equals, 2)
+		c.Assert(events, HasLen, 3)
+		return []*goes.Event{goes.NewEvent("", "FooEvent", &FooEvent{Foo: "bar"}, nil)}, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(ws.count, Equals, 4)
+	c.Assert(ws.appends, Equals, 1)
+}
+
+func (s *RetrySuite) TestWithOptimisticRetryCreatesStream(c *C) {
+	ws := newWritableStream(c, "SomeStream", 0)
+
+	err := client.WithOptimisticRetry("SomeStream", 1, func(version int, events []*goes.EventResponse) ([]*goes.Event, error) {
+		c.Assert(version, Equals, -1)
+		c.Assert(events, HasLen, 0)
+		return []*goes.Event{goes.NewEvent("", "FooEvent", &FooEvent{Foo: "bar"}, nil)}, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(ws.count, Equals, 1)
+}
+
+// Tests that after a conflict only the events written by the other writer are
+// read and that decide is called with all of the events of the stream.
+func (s *RetrySuite) TestWithOptimisticRetryRereadsNewEvents(c *C) {
+	ws := newWritableStream(c, "SomeStream", 3)
+	conflicts := 2
+	ws.beforeAppend = func() {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		if conflicts > 0 {
+			conflicts--
+			ws.write(1)
+		}
+	}
+
+	var versions []int
+	err := client.WithOptimisticRetry("SomeStream", 3, func(version int, events []*goes.EventResponse) ([]*goes.Event, error) {
+		versions = append(versions, version)
+		c.Assert(events, HasLen, version+1)
+		for i, e := range events {
+			c.Assert(e.Event.EventNumber, Equals, i)
+		}
+		return []*goes.Event{goes.NewEvent("", "FooEvent", &FooEvent{Foo: "bar"}, nil)}, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(versions, DeepEquals, []int{2, 3, 4})
+	c.Assert(ws.count, Equals, 6)
+	for n := 0; n < 5; n++ {
+		c.Assert(ws.reads[n], Equals, 1, Commentf("event %d", n))
+	}
+}
+
+func (s *RetrySuite) TestWithOptimisticRetryGivesUp(c *C) {
+	ws := newWritableStream(c, "SomeStream", 1)
+	ws.beforeAppend = func() {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		ws.write(1)
+	}
+
+	calls := 0
+	err := client.WithOptimisticRetry("SomeStream", 2, func(version int, events []*goes.EventResponse) ([]*goes.Event, error) {
+		calls++
+		return []*goes.Event{goes.NewEvent("", "FooEvent", &FooEvent{Foo: "bar"}, nil)}, nil
+	})
+	c.Assert(err, FitsTypeOf, &goes.ErrConcurrencyViolation{})
+	c.Assert(err.(*goes.ErrConcurrencyViolation).CurrentVersion, Equals, 2)
+	c.Assert(calls, Equals, 2)
+	c.Assert(ws.appends, Equals, 2)
+}
+
+func (s *RetrySuite) TestWithOptimisticRetryDecideError(c *C) {
+	ws := newWritableStream(c, "SomeStream", 1)
+	decideErr := errors.New("rejected")
+
+	err := client.WithOptimisticRetry("SomeStream", 3, func(version int, events []*goes.EventResponse) ([]*goes.Event, error) {
+		return nil, decideErr
+	})
+	c.Assert(err, Equals, decideErr)
+	c.Assert(ws.appends, Equals, 0)
+}
+
+func (s *RetrySuite) TestWithOptimisticRetryNothingToWrite(c *C) {
+	ws := newWritableStream(c, "SomeStream", 1)
+
+	err := client.WithOptimisticRetry("SomeStream", 3, func(version int, events []*goes.EventResponse) ([]*goes.Event, error) {
+		return nil, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(ws.appends, Equals, 0)
+}
+
+// Tests that an error is returned when the stream cannot be read up to the
+// current version reported with a conflict.
+func (s *RetrySuite) TestWithOptimisticRetryStaleRead(c *C) {
+	ws := newWritableStream(c, "SomeStream", 2)
+	ws.beforeAppend = func() {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		ws.lag = 1
+		ws.write(1)
+	}
+
+	calls := 0
+	err := client.WithOptimisticRetry("SomeStream", 3, func(version int, events []*goes.EventResponse) ([]*goes.Event, error) {
+		calls++
+		return []*goes.Event{goes.NewEvent("", "FooEvent", &FooEvent{Foo: "bar"}, nil)}, nil
+	})
+	c.Assert(err, ErrorMatches, "The stream SomeStream was read to version 1 which is before its current version 2.")
+	c.Assert(calls, Equals, 1)
+	c.Assert(ws.appends, Equals, 1)
+}
+
+// Tests that the stream is read without long polling when the client sets
+// ES-LongPoll.
+func (s *RetrySuite) TestWithOptimisticRetryDoesNotLongPoll(c *C) {
+	ws := newWritableStream(c, "SomeStream", 3)
+	client.SetHeader("ES-LongPoll", "30")
+
+	err := client.WithOptimisticRetry("SomeStream", 1, func(version int, events []*goes.EventResponse) ([]*goes.Event, error) {
+		c.Assert(version, Equals, 2)
+		return nil, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(ws.longPolls, Equals, 0)
+}
@@ -6,10 +6,11 @@
 package goes
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"strconv"
-	"strings"
+	"sort"
+	"time"
 
 	"github.com/jetbasrawi/go.geteventstore/atom"
 )
@@ -18,15 +19,29 @@ import (
 type StreamReader struct {
 	streamName    string
 	client        *Client
+	opts          []RequestOption
+	ctx           context.Context
+	longPoll      int
+	longPollSet   bool
+	callOpts      []RequestOption
 	version       int
 	nextVersion   int
 	index         int
 	currentURL    string
 	pageSize      int
+	feedPageSize  int
+	minPageSize   int
+	maxPageSize   int
 	eventResponse *EventResponse
 	feedPage      *atom.Feed
 	lasterr       error
 	loadFeedPage  bool
+	prefetchDepth int
+	prefetcher    *prefetcher
+	fetchWorkers  int
+	pageEvents    []*EventResult
+	filters       []EventFilter
+	resolveLinks  bool
 }
 
 // Err returns any error that is raised as a result of a call to Next().
@@ -41,6 +56,7 @@ func (s *StreamReader) Version() int {
 
 // NextVersion is the version of the stream that will be returned by a call to Next().
 func (s *StreamReader) NextVersion(version int) {
+	s.stopPrefetch()
 	s.nextVersion = version
 }
 
@@ -62,8 +78,44 @@ func (s *StreamReader) EventResponse() *EventResponse {
 // under what conditions to exit the loop.
 //
 // When next is called, it will go to the eventstore and get a single event at the
-// current reader's stream version.
+// current reader's stream version. If prefetching has been enabled with Prefetch,
+// the event will usually already have been read in the background.
 func (s *StreamReader) Next() bool {
+	if s.prefetchDepth > 0 {
+		return s.nextPrefetched()
+	}
+	return s.next()
+}
+
+// NextWithOptions gets the next event on the stream like Next, applying the
+// options provided to the requests made for this call after the reader's
+// options.
+//
+// NextWithOptions always reads from the eventstore, so if prefetching has been
+// enabled with Prefetch the events read in the background are discarded.
+func (s *StreamReader) NextWithOptions(opts ...RequestOption) bool {
+	s.stopPrefetch()
+	s.callOpts = opts
+	defer func() { s.callOpts = nil }()
+	return s.next()
+}
+
+// next reads the event at the reader's next version from the eventstore,
+// skipping any events excluded by the reader's filters.
+func (s *StreamReader) next() bool {
+	for {
+		ok, skipped := s.read()
+		if !skipped {
+			return ok
+		}
+	}
+}
+
+// read reads the event at the reader's next version from the eventstore.
+//
+// skipped is true if the event was excluded by the reader's filters. The
+// version of the reader is advanced past skipped events.
+func (s *StreamReader) read() (ok bool, skipped bool) {
 	s.lasterr = nil
 
 	numEntries := 0
@@ -79,9 +131,10 @@ func (s *StreamReader) Next() bool {
 		url, err := s.client.GetFeedPath(s.streamName, "forward", s.nextVersion, s.pageSize)
 		if err != nil {
 			s.lasterr = err
-			return false
+			return false, false
 		}
 		s.currentURL = url
+		s.feedPageSize = s.pageSize
 	}
 
 	// If the index is less than 0 load the previous feed page.
@@ -94,42 +147,289 @@ func (s *StreamReader) Next() bool {
 			// of the stream, the previous link in the feedpage will be nil.
 			if l := s.feedPage.GetLink("previous"); l != nil {
 				s.currentURL = l.Href
+				if s.pageSize != s.feedPageSize {
+					// The page size has changed so the page size of the link,
+					// which is that of the current page, is replaced.
+					url, err := pageSizeURL(l.Href, s.pageSize)
+					if err != nil {
+						s.lasterr = err
+						return false, false
+					}
+					s.currentURL = url
+					s.feedPageSize = s.pageSize
+				}
 			}
 		}
 
 		//Read the feedpage at the current url
-		f, _, err := s.client.ReadFeed(s.currentURL)
+		f, _, err := s.client.ReadFeed(s.currentURL, s.feedOptions()...)
 		if err != nil {
 			s.lasterr = err
-			return true
+			return true, false
 		}
 
 		s.feedPage = f
 		numEntries = len(f.Entry)
 		s.index = numEntries - 1
+		s.adaptPageSize(numEntries)
+
+		// Read the events of the page concurrently if the reader has been
+		// configured to do so.
+		s.pageEvents = nil
+		if s.fetchWorkers > 1 && numEntries > 1 {
+			s.pageEvents = s.fetchPageEvents(f)
+		}
 	}
 
 	//If there are no events returned at the url return an error
 	if numEntries <= 0 {
 		s.eventResponse = nil
 		s.lasterr = &ErrNoMoreEvents{}
-		return true
+		return true, false
+	}
+
+	// The version of the reader is taken from the position of the entry where
+	// it is known so that the version is correct for streams with gaps in their
+	// event numbers.
+	if pos, ok := entryPosition(s.streamName, s.feedPage.Entry[s.index]); ok {
+		s.nextVersion = pos
+	}
+
+	// Skip the event without reading it if the entry is excluded by the
+	// reader's filters.
+	if !s.matchEntry(s.feedPage.Entry[s.index]) {
+		s.skip()
+		return true, true
 	}
 
 	//There are events returned, get the event for the current version
-	entry := s.feedPage.Entry[s.index]
-	url := strings.TrimRight(entry.Link[1].Href, "/")
-	e, _, err := s.client.GetEvent(url)
+	var e *EventResponse
+	var err error
+	if s.pageEvents != nil && s.pageEvents[s.index] != nil {
+		r := s.pageEvents[s.index]
+		e, err = r.EventResponse, r.Err
+		if err != nil {
+			// The events read concurrently are discarded so that the
+			// event is read again on the next call.
+			s.pageEvents = nil
+		}
+	} else {
+		var url string
+		url, err = s.feedPage.Entry[s.index].EventURL()
+		if err == nil {
+			e, _, err = s.client.GetEvent(url, s.requestOptions()...)
+		}
+	}
 	if err != nil {
 		s.lasterr = err
-		return true
+		return true, false
 	}
+	if err := s.resolveLink(e, s.feedPage.Entry[s.index]); err != nil {
+		// The reader advances past the unresolved link so that reading can
+		// continue with the next event.
+		s.lasterr = err
+		s.eventResponse = e
+		s.version = s.nextVersion
+		s.nextVersion++
+		s.index--
+		return true, false
+	}
+	if !s.matchEvent(e) {
+		s.skip()
+		return true, true
+	}
+
 	s.eventResponse = e
 	s.version = s.nextVersion
 	s.nextVersion++
 	s.index--
 
-	return true
+	return true, false
+}
+
+// skip advances the reader past the event at the current index.
+func (s *StreamReader) skip() {
+	s.eventResponse = nil
+	s.version = s.nextVersion
+	s.nextVersion++
+	s.index--
+}
+
+// fetchPageEvents reads the events of the feed page that are accepted by the
+// reader's filters concurrently. The results are indexed in the same order as
+// the entries of the page and are nil for entries that are excluded or
+// malformed.
+func (s *StreamReader) fetchPageEvents(f *atom.Feed) []*EventResult {
+	var fetch []string
+	var indices []int
+	for i, entry := range f.Entry {
+		if !s.matchEntry(entry) {
+			continue
+		}
+		u, err := entry.EventURL()
+		if err != nil {
+			continue
+		}
+		fetch = append(fetch, u)
+		indices = append(indices, i)
+	}
+
+	results := make([]*EventResult, len(f.Entry))
+	for i, r := range s.client.GetEvents(fetch, s.fetchWorkers, s.requestOptions()...) {
+		results[indices[i]] = r
+	}
+	return results
+}
+
+// SeekTime positions the reader at the first event in the stream that was
+// written at or after t.
+//
+// The feed pages of the stream are binary searched by the positions and
+// updated times of their entries, so streams that have been truncated or have
+// gaps in their event numbers are searched correctly. After SeekTime returns,
+// Next continues reading the stream forward from the event found. If all of
+// the events in the stream were written before t the reader is positioned at
+// the head of the stream.
+func (s *StreamReader) SeekTime(t time.Time) error {
+	s.stopPrefetch()
+
+	// The head page of the stream bounds the search.
+	head, _, err := s.seekPage("backward", -1)
+	if err != nil {
+		return err
+	}
+	if len(head) == 0 {
+		s.seekVersion(-1, 0)
+		return nil
+	}
+	if last := head[len(head)-1]; last.updated.Before(t) {
+		s.seekVersion(last.position, last.position+1)
+		return nil
+	}
+	if i := firstAtOrAfter(head, t); i > 0 {
+		s.seekVersion(head[i-1].position, head[i].position)
+		return nil
+	}
+
+	// The event found is the first event at or after position hi, which is
+	// the event at position at. All events before position lo were written
+	// before t and before is the position of the last of them.
+	lo, hi := 0, head[0].position
+	before, at := -1, head[0].position
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		page, next, err := s.seekPage("forward", mid)
+		if err != nil {
+			return err
+		}
+
+		switch i := firstAtOrAfter(page, t); {
+		case len(page) == 0:
+			// The page lies below the start of a stream truncated with
+			// $tb or $maxCount, so the search continues from the page
+			// the previous link points to.
+			lo = mid + 1
+			if next > lo {
+				lo = next
+			}
+			if lo > hi {
+				lo = hi
+			}
+		case i == 0:
+			hi, at = mid, page[0].position
+		case i < len(page):
+			s.seekVersion(page[i-1].position, page[i].position)
+			return nil
+		default:
+			before = page[i-1].position
+			lo = before + 1
+		}
+	}
+
+	s.seekVersion(before, at)
+	return nil
+}
+
+// seekEntry is the position and updated time of a feed entry.
+type seekEntry struct {
+	position int
+	updated  time.Time
+}
+
+// seekPage reads a page of the stream for SeekTime and returns its entries in
+// ascending order of position. next is the version the previous link of the
+// page starts from, or -1 if the page has no such link.
+//
+// Links are not resolved and the reader does not long poll, so the entries are
+// those of the stream itself and the request returns immediately.
+func (s *StreamReader) seekPage(direction string, version int) (entries []seekEntry, next int, err error) {
+	url, err := s.client.GetFeedPath(s.streamName, direction, version, s.pageSize)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	opts := append(s.requestOptions(), WithResolveLinkTos(false), WithLongPoll(0))
+	f, _, err := s.client.ReadFeed(url, opts...)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	next = -1
+	if l := f.GetLink("previous"); l != nil {
+		if v, ok := pageVersion(l.Href); ok {
+			next = v
+		}
+	}
+
+	entries = make([]seekEntry, len(f.Entry))
+	for i, e := range f.Entry {
+		position, ok := entryPosition(s.streamName, e)
+		if !ok {
+			return nil, -1, fmt.Errorf("The position of entry %s of stream %s is unknown.", e.ID, s.streamName)
+		}
+		updated, err := e.UpdatedTime()
+		if err != nil {
+			return nil, -1, err
+		}
+		entries[i] = seekEntry{position: position, updated: updated}
+	}
+
+	// The entries of feed pages are in descending order in both directions.
+	sort.Slice(entries, func(i, j int) bool { return entries[i].position < entries[j].position })
+	return entries, next, nil
+}
+
+// firstAtOrAfter returns the index of the first of the entries that was
+// written at or after t, or the number of entries if there is none.
+func firstAtOrAfter(entries []seekEntry, t time.Time) int {
+	for i, e := range entries {
+		if !e.updated.Before(t) {
+			return i
+		}
+	}
+	return len(entries)
+}
+
+// seekVersion positions the reader after the event at position version so
+// that the next event read is the first event at or after position next.
+func (s *StreamReader) seekVersion(version, next int) {
+	s.seek(readerPosition{version: version, nextVersion: next, index: -1, pageSize: s.pageSize})
+}
+
+// ReadRange reads the events from event number from to event number to
+// inclusive and returns them in ascending order.
+//
+// ReadRange does not change the position of the reader. The reader's options
+// are used for the requests. Fewer events will be returned if the end of the
+// stream is reached before the event number to or if the stream has gaps in its
+// event numbers.
+func (s *StreamReader) ReadRange(from, to int) ([]*EventResponse, error) {
+	if from < 0 || to < from {
+		return nil, fmt.Errorf("Invalid range [%d, %d].", from, to)
+	}
+
+	events, _, err := s.client.readEvents(s.streamName, from, to-from+1, "forward", to, s.requestOptions())
+	return events, err
 }
 
 // Scan deserializes event and event metadata into the types passed in
@@ -140,12 +440,18 @@ func (s *StreamReader) Scan(e interface{}, m interface{}) error {
 		return s.lasterr
 	}
 
-	if s.eventResponse == nil {
+	return scanEvent(s.eventResponse, e, m)
+}
+
+// scanEvent deserializes the event data and metadata of the event response
+// into e and m.
+func scanEvent(er *EventResponse, e interface{}, m interface{}) error {
+	if er == nil {
 		return &ErrNoMoreEvents{}
 	}
 
 	if e != nil {
-		data, ok := s.eventResponse.Event.Data.(*json.RawMessage)
+		data, ok := er.Event.Data.(*json.RawMessage)
 		if !ok {
 			return fmt.Errorf("Could not unmarshal the event. Event data is not of type *json.RawMessage")
 		}
@@ -155,8 +461,8 @@ func (s *StreamReader) Scan(e interface{}, m interface{}) error {
 		}
 	}
 
-	if m != nil && s.EventResponse().Event.MetaData != nil {
-		meta, ok := s.eventResponse.Event.MetaData.(*json.RawMessage)
+	if m != nil && er.Event.MetaData != nil {
+		meta, ok := er.Event.MetaData.(*json.RawMessage)
 		if !ok {
 			return fmt.Errorf("Could not unmarshal the event. Event data is not of type *json.RawMessage")
 		}
@@ -169,6 +475,89 @@ func (s *StreamReader) Scan(e interface{}, m interface{}) error {
 	return nil
 }
 
+// PageSize sets the number of entries requested in each feed page.
+//
+// An error is returned if size is less than 1 or greater than MaxPageSize.
+// The default page size is DefaultPageSize. Setting the page size disables
+// adaptive page sizing.
+func (s *StreamReader) PageSize(size int) error {
+	if err := validatePageSize(size); err != nil {
+		return err
+	}
+
+	s.stopPrefetch()
+	s.pageSize = size
+	s.minPageSize, s.maxPageSize = 0, 0
+	return nil
+}
+
+// AdaptivePageSize causes the reader to adjust the size of the feed pages it
+// requests as it reads the stream.
+//
+// The reader starts with pages of min entries. Each time a full page is read,
+// which happens while the reader is catching up with the stream, the size of the
+// next page is doubled up to max. When a page that is not full is read, which
+// happens at the head of the stream, the page size returns to min.
+//
+// An error is returned if min or max is less than 1 or greater than MaxPageSize
+// or if min is greater than max.
+func (s *StreamReader) AdaptivePageSize(min, max int) error {
+	if err := validatePageSize(min); err != nil {
+		return err
+	}
+	if err := validatePageSize(max); err != nil {
+		return err
+	}
+	if min > max {
+		return fmt.Errorf("Invalid page size range. Min %d is greater than max %d.", min, max)
+	}
+
+	s.stopPrefetch()
+	s.pageSize = min
+	s.minPageSize, s.maxPageSize = min, max
+	return nil
+}
+
+// adaptPageSize sets the size of the next page requested when adaptive page
+// sizing is enabled, based on the number of entries in the page just read.
+func (s *StreamReader) adaptPageSize(numEntries int) {
+	if s.maxPageSize == 0 {
+		return
+	}
+
+	if numEntries < s.feedPageSize {
+		s.pageSize = s.minPageSize
+		return
+	}
+
+	s.pageSize = s.feedPageSize * 2
+	if s.pageSize > s.maxPageSize {
+		s.pageSize = s.maxPageSize
+	}
+}
+
+func validatePageSize(size int) error {
+	if size < 1 || size > MaxPageSize {
+		return fmt.Errorf("Invalid page size %d. Page size must be between 1 and %d.", size, MaxPageSize)
+	}
+	return nil
+}
+
+// FetchConcurrency sets the number of events of a feed page that the reader
+// will read from the eventstore at the same time.
+//
+// When workers is greater than 1, all of the events of a feed page are read
+// concurrently, by at most workers requests at a time, when the page is
+// loaded. The events are still returned by Next in stream order. If reading
+// an event fails the error is returned by Next at the position of the event
+// and the event is read again on the following call to Next.
+//
+// The default is 1, which reads events one at a time as Next is called.
+func (s *StreamReader) FetchConcurrency(workers int) {
+	s.stopPrefetch()
+	s.fetchWorkers = workers
+}
+
 // LongPoll causes the server to wait up to the number of seconds specified
 // for results to become available at the URL requested.
 //
@@ -181,27 +570,65 @@ func (s *StreamReader) Scan(e interface{}, m interface{}) error {
 // Setting the argument seconds to any integer value above 0 will cause the
 // request to be made with ES-LongPoll set to that value. Any value 0 or below
 // will cause the request to be made without ES-LongPoll and the server will not
-// wait to return.
+// wait to return, even if ES-LongPoll has been set on the client with SetHeader.
+//
+// Until LongPoll is called the reader uses the client's headers. Once it has
+// been called, requests for events are always made without ES-LongPoll.
 func (s *StreamReader) LongPoll(seconds int) {
-	if seconds > 0 {
-		s.client.SetHeader("ES-LongPoll", strconv.Itoa(seconds))
-	} else {
-		s.client.DeleteHeader("ES-LongPoll")
+	s.stopPrefetch()
+	s.longPoll = seconds
+	s.longPollSet = true
+}
+
+// requestOptions returns the options used for requests made by the reader.
+//
+// The options passed to NextWithOptions are applied last so that they take
+// precedence over the reader's options.
+func (s *StreamReader) requestOptions() []RequestOption {
+	return s.options(false)
+}
+
+// feedOptions returns the options used when reading feed pages.
+//
+// The reader's long poll setting is applied after the reader's options.
+func (s *StreamReader) feedOptions() []RequestOption {
+	return s.options(true)
+}
+
+func (s *StreamReader) options(feed bool) []RequestOption {
+	opts := make([]RequestOption, 0, len(s.opts)+len(s.callOpts)+4)
+	opts = append(opts, s.opts...)
+	if s.resolveLinks {
+		opts = append(opts, WithResolveLinkTos(true))
 	}
+	if s.ctx != nil {
+		opts = append(opts, WithContext(s.ctx))
+	}
+	if s.longPollSet {
+		if feed {
+			opts = append(opts, WithLongPoll(s.longPoll))
+		} else {
+			opts = append(opts, WithLongPoll(0))
+		}
+	}
+	return append(opts, s.callOpts...)
 }
 
 // MetaData gets the metadata for a stream.
 //
 // Stream metadata is retured as an EventResponse.
 //
+// Any options provided are applied after the reader's options.
+//
 // For more information on stream metadata see:
 // http://docs.geteventstore.com/http-api/3.7.0/stream-metadata/
-func (s *StreamReader) MetaData() (*EventResponse, error) {
-	url, _, err := s.client.GetMetadataURL(s.streamName)
+func (s *StreamReader) MetaData(opts ...RequestOption) (*EventResponse, error) {
+	opts = append(append([]RequestOption{}, s.opts...), opts...)
+	url, _, err := s.client.GetMetadataURL(s.streamName, opts...)
 	if err != nil {
 		return nil, err
 	}
-	ev, _, err := s.client.GetEvent(url)
+	ev, _, err := s.client.GetEvent(url, opts...)
 	if err != nil {
 		return nil, err
 	}
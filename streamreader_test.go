@@ -10,10 +10,16 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/jetbasrawi/go.geteventstore"
 	"github.com/jetbasrawi/go.geteventstore.testfeed"
+	"github.com/jetbasrawi/go.geteventstore/atom"
 	. "gopkg.in/check.v1"
 )
 
@@ -266,7 +272,7 @@ func (s *StreamReaderSuite) TestGetMetaData(c *C) {
 	reader := client.NewStreamReader(stream)
 	got, err := reader.MetaData()
 	c.Assert(err, IsNil)
-	c.Assert(got.PrettyPrint(), Equals, want.PrettyPrint())
+	assertEventResponse(c, got, want)
 }
 
 func (s *StreamReaderSuite) TestGetMetaDataReturnsErrUnauthorizedWhenGettingMetaURL(c *C) {
@@ -364,3 +370,436 @@ func (s *StreamReaderSuite) TestGetMetaDataReturnsErrTemporarilyUnavailableWhenG
 	c.Assert(reflect.TypeOf(err).Elem().Name(), Equals, "ErrTemporarilyUnavailable")
 	c.Assert(m, IsNil)
 }
+
+// Tests that events read concurrently are returned in stream order.
+func (s *StreamReaderSuite) TestFetchConcurrencyReturnsEventsInOrder(c *C) {
+	streamName := "SomeStream"
+	ne := 45
+	es := mock.CreateTestEvents(ne, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	stream := client.NewStreamReader(streamName)
+	stream.FetchConcurrency(4)
+	for i := 0; i < ne; i++ {
+		c.Assert(stream.Next(), Equals, true)
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, i)
+		c.Assert(stream.EventResponse().Event.EventID, Equals, es[i].EventID)
+	}
+
+	stream.Next()
+	c.Assert(stream.Err(), DeepEquals, &goes.ErrNoMoreEvents{})
+}
+
+// Tests that an error reading one of the events of a page is returned at the
+// position of that event and that the event is read again on the next call.
+func (s *StreamReaderSuite) TestFetchConcurrencyReportsErrorsPerEvent(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(10, streamName, server.URL, "FooEvent")
+	u, _ := url.Parse(server.URL)
+	sim, _ := mock.NewAtomFeedSimulator(es, u, nil, -1)
+
+	var mu sync.Mutex
+	failures := 1
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fail := r.URL.Path == "/streams/SomeStream/4" && failures > 0
+		if fail {
+			failures--
+		}
+		mu.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		sim.ServeHTTP(w, r)
+	})
+
+	stream := client.NewStreamReader(streamName)
+	stream.FetchConcurrency(3)
+	for i := 0; i < 4; i++ {
+		stream.Next()
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, i)
+	}
+
+	stream.Next()
+	c.Assert(reflect.TypeOf(stream.Err()).Elem().Name(), Equals, "ErrTemporarilyUnavailable")
+	c.Assert(stream.Version(), Equals, 3)
+
+	for i := 4; i < 10; i++ {
+		stream.Next()
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, i)
+		c.Assert(stream.EventResponse().Event.EventID, Equals, es[i].EventID)
+	}
+}
+
+func (s *StreamReaderSuite) TestReadRange(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(25, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	stream := client.NewStreamReader(streamName)
+	got, err := stream.ReadRange(3, 12)
+	c.Assert(err, IsNil)
+	c.Assert(got, HasLen, 10)
+	for i, e := range got {
+		c.Assert(e.Event.EventNumber, Equals, i+3)
+	}
+
+	// The position of the reader is unchanged.
+	stream.Next()
+	c.Assert(stream.Err(), IsNil)
+	c.Assert(stream.Version(), Equals, 0)
+}
+
+func (s *StreamReaderSuite) TestReadRangeWithGaps(c *C) {
+	mux.Handle("/", gapSimulator("SomeStream", []int{0, 1, 2, 5, 6, 9, 10, 11}))
+
+	stream := client.NewStreamReader("SomeStream")
+	got, err := stream.ReadRange(1, 9)
+	c.Assert(err, IsNil)
+	c.Assert(eventNumbers(got), DeepEquals, []int{1, 2, 5, 6, 9})
+}
+
+func (s *StreamReaderSuite) TestReadRangeInvalidRange(c *C) {
+	stream := client.NewStreamReader("SomeStream")
+	_, err := stream.ReadRange(5, 4)
+	c.Assert(err, NotNil)
+}
+
+func (s *StreamReaderSuite) TestPageSize(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(30, streamName, server.URL, "FooEvent")
+	u, _ := url.Parse(server.URL)
+	sim, _ := mock.NewAtomFeedSimulator(es, u, nil, -1)
+
+	var mu sync.Mutex
+	var pages []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "forward") {
+			mu.Lock()
+			pages = append(pages, r.URL.Path)
+			mu.Unlock()
+		}
+		sim.ServeHTTP(w, r)
+	})
+
+	stream := client.NewStreamReader(streamName)
+	err := stream.PageSize(50)
+	c.Assert(err, IsNil)
+	for i := 0; i < 30; i++ {
+		stream.Next()
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, i)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	c.Assert(pages, DeepEquals, []string{"/streams/SomeStream/0/forward/50"})
+}
+
+func (s *StreamReaderSuite) TestPageSizeOutOfRange(c *C) {
+	stream := client.NewStreamReader("SomeStream")
+	c.Assert(stream.PageSize(0), NotNil)
+	c.Assert(stream.PageSize(goes.MaxPageSize+1), NotNil)
+	c.Assert(stream.PageSize(goes.MaxPageSize), IsNil)
+	c.Assert(stream.AdaptivePageSize(10, 5), NotNil)
+	c.Assert(stream.AdaptivePageSize(0, 5), NotNil)
+	c.Assert(stream.AdaptivePageSize(5, goes.MaxPageSize+1), NotNil)
+}
+
+// Tests that the page size grows while the reader is catching up and returns
+// to the minimum at the head of the stream.
+func (s *StreamReaderSuite) TestAdaptivePageSize(c *C) {
+	streamName := "SomeStream"
+	ne := 100
+	es := mock.CreateTestEvents(ne, streamName, server.URL, "FooEvent")
+	u, _ := url.Parse(server.URL)
+	sim, _ := mock.NewAtomFeedSimulator(es, u, nil, -1)
+
+	var mu sync.Mutex
+	var pages []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "forward") {
+			mu.Lock()
+			pages = append(pages, r.URL.Path)
+			mu.Unlock()
+		}
+		sim.ServeHTTP(w, r)
+	})
+
+	stream := client.NewStreamReader(streamName)
+	err := stream.AdaptivePageSize(5, 40)
+	c.Assert(err, IsNil)
+	for i := 0; i < ne; i++ {
+		stream.Next()
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, i)
+		c.Assert(stream.EventResponse().Event.EventID, Equals, es[i].EventID)
+	}
+	stream.Next()
+	c.Assert(stream.Err(), DeepEquals, &goes.ErrNoMoreEvents{})
+
+	mu.Lock()
+	defer mu.Unlock()
+	c.Assert(pages, DeepEquals, []string{
+		"/streams/SomeStream/0/forward/5",
+		"/streams/SomeStream/5/forward/10",
+		"/streams/SomeStream/15/forward/20",
+		"/streams/SomeStream/35/forward/40",
+		"/streams/SomeStream/75/forward/40",
+		"/streams/SomeStream/100/forward/5",
+	})
+}
+
+// Tests that a reader whose page size changes reads each event of a stream with
+// gaps in its event numbers once.
+func (s *StreamReaderSuite) TestAdaptivePageSizeWithGaps(c *C) {
+	numbers := []int{0, 1, 2, 5, 6, 9, 10, 11, 15, 16, 20, 30, 31, 32, 40, 41, 50}
+	sim := gapSimulator("SomeStream", numbers)
+
+	var mu sync.Mutex
+	var pages []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "forward") {
+			mu.Lock()
+			pages = append(pages, r.URL.Path)
+			mu.Unlock()
+		}
+		sim.ServeHTTP(w, r)
+	})
+
+	stream := client.NewStreamReader("SomeStream")
+	err := stream.AdaptivePageSize(2, 8)
+	c.Assert(err, IsNil)
+	for _, n := range numbers {
+		stream.Next()
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, n)
+		c.Assert(stream.EventResponse().Event.EventNumber, Equals, n)
+	}
+	stream.Next()
+	c.Assert(stream.Err(), DeepEquals, &goes.ErrNoMoreEvents{})
+
+	mu.Lock()
+	defer mu.Unlock()
+	c.Assert(pages, DeepEquals, []string{
+		"/streams/SomeStream/0/forward/2",
+		"/streams/SomeStream/2/forward/4",
+		"/streams/SomeStream/10/forward/8",
+		"/streams/SomeStream/33/forward/8",
+		"/streams/SomeStream/51/forward/2",
+	})
+}
+
+// The feed simulator writes event n one minute after event n-1.
+func (s *StreamReaderSuite) TestSeekTime(c *C) {
+	streamName := "SomeStream"
+	ne := 100
+	es := mock.CreateTestEvents(ne, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	base := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	stream := client.NewStreamReader(streamName)
+	for _, n := range []int{0, 1, 37, 63, 98, 99} {
+		err := stream.SeekTime(base.Add(time.Duration(n)*time.Minute - 30*time.Second))
+		c.Assert(err, IsNil)
+		c.Assert(stream.Next(), Equals, true)
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, n)
+		c.Assert(stream.EventResponse().Event.EventID, Equals, es[n].EventID)
+	}
+
+	// The reader continues forward from the event found.
+	err := stream.SeekTime(base.Add(90 * time.Minute))
+	c.Assert(err, IsNil)
+	for i := 90; i < ne; i++ {
+		stream.Next()
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, i)
+	}
+	stream.Next()
+	c.Assert(stream.Err(), DeepEquals, &goes.ErrNoMoreEvents{})
+}
+
+// Tests seeking in a stream that has been truncated and has gaps in its event
+// numbers. The gap simulator writes event n n minutes after the first event.
+func (s *StreamReaderSuite) TestSeekTimeWithGaps(c *C) {
+	numbers := []int{50, 51, 52, 60, 61, 75, 80, 81, 90, 120, 121, 140}
+	var requests []string
+	gaps := gapSimulator("SomeStream", numbers)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if pagePath.MatchString(r.URL.Path) {
+			requests = append(requests, r.URL.Path+" "+r.Header.Get("ES-ResolveLinkTos"))
+		}
+		gaps.ServeHTTP(w, r)
+	})
+
+	base := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	stream := client.NewStreamReader("SomeStream")
+	c.Assert(stream.PageSize(2), IsNil)
+	for _, tc := range []struct{ minutes, version, next int }{
+		{0, -1, 50},
+		{50, -1, 50},
+		{51, 50, 51},
+		{53, 52, 60},
+		{70, 61, 75},
+		{85, 81, 90},
+		{100, 90, 120},
+		{121, 120, 121},
+		{130, 121, 140},
+		{141, 140, 141},
+	} {
+		requests = nil
+		err := stream.SeekTime(base.Add(time.Duration(tc.minutes) * time.Minute))
+		c.Assert(err, IsNil)
+		c.Assert(stream.Version(), Equals, tc.version)
+		c.Assert(len(requests) <= 8, Equals, true, Commentf("%v", requests))
+		for _, r := range requests {
+			c.Assert(strings.HasSuffix(r, " false"), Equals, true)
+		}
+
+		if tc.next > 140 {
+			stream.Next()
+			c.Assert(stream.Err(), DeepEquals, &goes.ErrNoMoreEvents{})
+			continue
+		}
+		c.Assert(stream.Next(), Equals, true)
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, tc.next)
+		c.Assert(stream.EventResponse().Event.EventNumber, Equals, tc.next)
+	}
+}
+
+// Tests seeking in a stream truncated with $tb=50. As the eventstore does, the
+// server returns empty pages for forward reads that lie entirely before the
+// start of the stream.
+func (s *StreamReaderSuite) TestSeekTimeWithEmptyPages(c *C) {
+	var numbers []int
+	for n := 50; n < 60; n++ {
+		numbers = append(numbers, n)
+	}
+	gaps := gapSimulator("SomeStream", numbers)
+	empty := 0
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		m := pagePath.FindStringSubmatch(r.URL.Path)
+		if m == nil || m[3] != "forward" {
+			gaps.ServeHTTP(w, r)
+			return
+		}
+		v, _ := strconv.Atoi(m[2])
+		size, _ := strconv.Atoi(m[4])
+		if v+size > 50 {
+			gaps.ServeHTTP(w, r)
+			return
+		}
+
+		empty++
+		f, _ := mock.CreateTestFeed(nil, server.URL+r.URL.Path)
+		f.StreamID = "SomeStream"
+		f.Link = append(f.Link, atom.Link{Rel: "previous", Href: fmt.Sprintf("%s/streams/SomeStream/%d/forward/%d", server.URL, v+size, size)})
+		w.Header().Set("Content-Type", "application/atom+xml")
+		fmt.Fprint(w, f.PrettyPrint())
+	})
+
+	base := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	stream := client.NewStreamReader("SomeStream")
+	c.Assert(stream.PageSize(2), IsNil)
+	for _, tc := range []struct{ minutes, version, next int }{
+		{0, -1, 50},
+		{50, -1, 50},
+		{51, 50, 51},
+		{55, 54, 55},
+		{59, 58, 59},
+	} {
+		err := stream.SeekTime(base.Add(time.Duration(tc.minutes) * time.Minute))
+		c.Assert(err, IsNil)
+		c.Assert(stream.Version(), Equals, tc.version)
+		c.Assert(stream.Next(), Equals, true)
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, tc.next)
+	}
+	c.Assert(empty > 0, Equals, true)
+
+	err := stream.SeekTime(base.Add(60 * time.Minute))
+	c.Assert(err, IsNil)
+	c.Assert(stream.Version(), Equals, 59)
+}
+
+func (s *StreamReaderSuite) TestSeekTimeAfterLastEvent(c *C) {
+	streamName := "SomeStream"
+	es := mock.CreateTestEvents(10, streamName, server.URL, "FooEvent")
+	setupSimulator(es, nil)
+
+	stream := client.NewStreamReader(streamName)
+	err := stream.SeekTime(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(err, IsNil)
+	c.Assert(stream.Version(), Equals, 9)
+	stream.Next()
+	c.Assert(stream.Err(), DeepEquals, &goes.ErrNoMoreEvents{})
+}
+
+func (s *StreamReaderSuite) TestSeekTimeStreamDoesNotExist(c *C) {
+	stream := client.NewStreamReader("DoesNotExist")
+	err := stream.SeekTime(time.Now())
+	c.Assert(reflect.TypeOf(err).Elem().Name(), Equals, "ErrNotFound")
+}
+
+// oddFeedHandler serves the first page of the stream with the links of the
+// entries changed by modify and serves everything else with the simulator.
+func oddFeedHandler(es []*mock.Event, modify func(f *atom.Feed)) {
+	u, _ := url.Parse(server.URL)
+	sim, _ := mock.NewAtomFeedSimulator(es, u, nil, -1)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/streams/SomeStream/0/forward/20" {
+			f, _ := mock.CreateTestFeed(es, server.URL+r.URL.Path)
+			modify(f)
+			fmt.Fprint(w, f.PrettyPrint())
+			return
+		}
+		sim.ServeHTTP(w, r)
+	})
+}
+
+// Tests that the reader finds the event url of entries by link relation when
+// the links are reordered or an entry has only one link.
+func (s *StreamReaderSuite) TestNextToleratesLinkOrder(c *C) {
+	es := mock.CreateTestEvents(3, "SomeStream", server.URL, "FooEvent")
+	oddFeedHandler(es, func(f *atom.Feed) {
+		u := server.URL + "/streams/SomeStream/2"
+		f.Entry[0].Link = []atom.Link{{Rel: "alternate", Href: u}, {Rel: "edit", Href: server.URL + "/other"}}
+		f.Entry[1].Link = f.Entry[1].Link[:1]
+	})
+
+	stream := client.NewStreamReader("SomeStream")
+	for i := 0; i < 3; i++ {
+		stream.Next()
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.EventResponse().Event.EventID, Equals, es[i].EventID)
+	}
+}
+
+func (s *StreamReaderSuite) TestNextReturnsErrorForMalformedEntry(c *C) {
+	es := mock.CreateTestEvents(3, "SomeStream", server.URL, "FooEvent")
+	oddFeedHandler(es, func(f *atom.Feed) {
+		f.Entry[1].Link = nil
+	})
+
+	for _, workers := range []int{0, 3} {
+		stream := client.NewStreamReader("SomeStream")
+		stream.FetchConcurrency(workers)
+
+		stream.Next()
+		c.Assert(stream.Err(), IsNil)
+		c.Assert(stream.Version(), Equals, 0)
+
+		stream.Next()
+		e, ok := stream.Err().(*atom.ErrMalformedEntry)
+		c.Assert(ok, Equals, true)
+		c.Assert(e.Title, Equals, "1@SomeStream")
+		c.Assert(stream.Version(), Equals, 0)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // StreamWriter provides methods for writing events and metadata to an
@@ -16,21 +17,34 @@ import (
 type StreamWriter struct {
 	client     *Client
 	streamName string
+	opts       []RequestOption
 }
 
 // Append writes an event to the head of the stream.
 //
 // If the stream does not exist, it will be created.
 //
-// There are some special version numbers that can be provided.
+// The expected version is the version the stream is expected to be at, such as
+// Any(), NoStream(), EmptyStream(), StreamExists() or Exact(n). The zero value
+// of ExpectedVersion writes to the stream without checking its version.
 // http://docs.geteventstore.com/http-api/3.7.0/writing-to-a-stream/
 //
-// -2 : The write should never conflict with anything and should always succeed.
-//
-// -1 : The stream should not exist at the time of writing. This write will create it.
-//
-// 0 : The stream should exist but it should be empty.
-func (s *StreamWriter) Append(expectedVersion *int, events ...*Event) error {
+// If the expected version does not match the version of the stream an
+// *ErrConcurrencyViolation is returned containing the current version of the
+// stream. Other invalid requests, such as events that are malformed, return an
+// *ErrBadRequest. An invalid expected version, such as Exact(-1), returns an
+// error without making a request.
+func (s *StreamWriter) Append(expectedVersion ExpectedVersion, events ...*Event) error {
+	return s.AppendWithOptions(expectedVersion, events)
+}
+
+// AppendWithOptions writes events to the head of the stream like Append,
+// applying the options provided to the request after the writer's options.
+func (s *StreamWriter) AppendWithOptions(expectedVersion ExpectedVersion, events []*Event, opts ...RequestOption) error {
+	if err := expectedVersion.validate(); err != nil {
+		return err
+	}
+
 	u := fmt.Sprintf("/streams/%s", s.streamName)
 	req, err := s.client.NewRequest(http.MethodPost, u, events)
 	if err != nil {
@@ -38,14 +52,17 @@ func (s *StreamWriter) Append(expectedVersion *int, events ...*Event) error {
 	}
 
 	req.Header.Set("Content-Type", "application/vnd.eventstore.events+json")
-	if expectedVersion != nil {
-		req.Header.Set("ES-ExpectedVersion", strconv.Itoa(*expectedVersion))
+	setExpectedVersion(req, expectedVersion)
+	applyOptions(req, s.opts)
+	applyOptions(req, opts)
+	if err := checkExpectedVersion(req); err != nil {
+		return err
 	}
 
 	_, err = s.client.Do(req, nil)
 	if err != nil {
-		if e, ok := err.(*ErrBadRequest); ok {
-			return &ErrConcurrencyViolation{ErrorResponse: e.ErrorResponse}
+		if e, ok := err.(*ErrBadRequest); ok && isWrongExpectedVersion(e.ErrorResponse) {
+			return newErrConcurrencyViolation(e.ErrorResponse)
 		}
 		return err
 	}
@@ -53,6 +70,41 @@ func (s *StreamWriter) Append(expectedVersion *int, events ...*Event) error {
 	return nil
 }
 
+// wrongExpectedVersion is the reason phrase of the response to a write with an
+// expected version that does not match the version of the stream.
+const wrongExpectedVersion = "Wrong expected EventNumber"
+
+// isWrongExpectedVersion reports whether a response was returned because the
+// expected version of a write did not match the version of the stream rather
+// than because the request was invalid.
+//
+// The response must be a bad request with either the reason phrase of a wrong
+// expected version or the current version of the stream in its headers.
+func isWrongExpectedVersion(r *ErrorResponse) bool {
+	if r == nil || r.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	if strings.EqualFold(r.Reason, wrongExpectedVersion) {
+		return true
+	}
+	_, ok := currentVersion(r)
+	return ok
+}
+
+// currentVersion returns the current version of the stream reported in the
+// ES-CurrentVersion header of the response.
+func currentVersion(r *ErrorResponse) (int, bool) {
+	v, err := strconv.Atoi(r.Header.Get("ES-CurrentVersion"))
+	return v, err == nil
+}
+
+// newErrConcurrencyViolation returns an *ErrConcurrencyViolation with the
+// current version of the stream if it was reported by the server.
+func newErrConcurrencyViolation(r *ErrorResponse) *ErrConcurrencyViolation {
+	current, ok := currentVersion(r)
+	return &ErrConcurrencyViolation{ErrorResponse: r, CurrentVersion: current, HasCurrentVersion: ok}
+}
+
 // WriteMetaData writes the metadata for a stream.
 //
 // The operation will replace the current stream metadata.
@@ -60,6 +112,10 @@ func (s *StreamWriter) Append(expectedVersion *int, events ...*Event) error {
 // For more information on stream metadata see:
 // http://docs.geteventstore.com/http-api/3.7.0/stream-metadata/
 //
+// The expected version is the version the metadata stream is expected to be at
+// and is used in the same way as for Append. An invalid expected version
+// returns an error without making a request.
+//
 // If the metadata was written successfully the error returned will be nil.
 //
 // If an error occurs the error returned may be an ErrUnauthorized, a
@@ -68,9 +124,16 @@ func (s *StreamWriter) Append(expectedVersion *int, events ...*Event) error {
 // for inspection as an ErrorResponse field on the error.
 // If an error occurred outside of the http request another type of error will be returned
 // such as a *url.Error in cases where the streamwriter is unable to connect to the server.
-func (s *StreamWriter) WriteMetaData(stream string, metadata interface{}) error {
+//
+// Any options provided are applied after the writer's options.
+func (s *StreamWriter) WriteMetaData(stream string, expectedVersion ExpectedVersion, metadata interface{}, opts ...RequestOption) error {
+	if err := expectedVersion.validate(); err != nil {
+		return err
+	}
+
+	opts = append(append([]RequestOption{}, s.opts...), opts...)
 	m := NewEvent("", "MetaData", metadata, nil)
-	mURL, _, err := s.client.GetMetadataURL(stream)
+	mURL, _, err := s.client.GetMetadataURL(stream, opts...)
 	if err != nil {
 		return err
 	}
@@ -80,6 +143,11 @@ func (s *StreamWriter) WriteMetaData(stream string, metadata interface{}) error
 	}
 
 	req.Header.Set("Content-Type", "application/vnd.eventstore.events+json")
+	setExpectedVersion(req, expectedVersion)
+	applyOptions(req, opts)
+	if err := checkExpectedVersion(req); err != nil {
+		return err
+	}
 
 	_, err = s.client.Do(req, nil)
 	if err != nil {
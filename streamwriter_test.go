@@ -54,7 +54,7 @@ func (s *StreamWriterSuite) TestAppendEventsSingle(c *C) {
 	})
 
 	streamWriter := client.NewStreamWriter(streamName)
-	err := streamWriter.Append(nil, ev)
+	err := streamWriter.Append(goes.ExpectedVersion{}, ev)
 
 	c.Assert(err, IsNil)
 }
@@ -84,7 +84,7 @@ func (s *StreamWriterSuite) TestAppendEventsMultiple(c *C) {
 	})
 
 	streamWriter := client.NewStreamWriter(stream)
-	err := streamWriter.Append(nil, ev1, ev2)
+	err := streamWriter.Append(goes.ExpectedVersion{}, ev1, ev2)
 
 	c.Assert(err, IsNil)
 }
@@ -106,15 +106,161 @@ func (s *StreamWriterSuite) TestAppendEventsWithErrConcurrencyViolation(c *C) {
 		got := r.Header.Get("ES-ExpectedVersion")
 		c.Assert(got, Equals, want)
 
+		w.Header().Set("ES-CurrentVersion", "7")
 		w.WriteHeader(http.StatusBadRequest)
 		fmt.Fprint(w, "")
 
 	})
 
 	streamWriter := client.NewStreamWriter(stream)
-	err := streamWriter.Append(&expectedVersion, ev)
+	err := streamWriter.Append(goes.Exact(expectedVersion), ev)
 	c.Assert(err, NotNil)
 	c.Assert(reflect.TypeOf(err).Elem().Name(), DeepEquals, "ErrConcurrencyViolation")
+	c.Assert(err.(*goes.ErrConcurrencyViolation).CurrentVersion, Equals, 7)
+	c.Assert(err.(*goes.ErrConcurrencyViolation).HasCurrentVersion, Equals, true)
+}
+
+// Tests that a concurrency violation is recognised by the reason phrase of the
+// response when the server does not return the current version.
+func (s *StreamWriterSuite) TestAppendEventsWithWrongExpectedEventNumber(c *C) {
+	ev := goes.NewEvent("", "SomeEventType", &MyDataType{Field1: 445}, nil)
+	mux.HandleFunc("/streams/Some-Stream", func(w http.ResponseWriter, r *http.Request) {
+		// The reason phrase cannot be set with a ResponseWriter so the
+		// response is written to the connection.
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		c.Assert(err, IsNil)
+		defer conn.Close()
+		fmt.Fprint(buf, "HTTP/1.1 400 Wrong expected EventNumber\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+		buf.Flush()
+	})
+
+	expectedVersion := 5
+	err := client.NewStreamWriter("Some-Stream").Append(goes.Exact(expectedVersion), ev)
+	e, ok := err.(*goes.ErrConcurrencyViolation)
+	c.Assert(ok, Equals, true)
+	c.Assert(e.HasCurrentVersion, Equals, false)
+	c.Assert(e.ErrorResponse.Reason, Equals, "Wrong expected EventNumber")
+}
+
+// Tests that a bad request is only returned as a concurrency violation when the
+// current version reported by the server is a version.
+func (s *StreamWriterSuite) TestAppendWithInvalidCurrentVersionReturnsErrBadRequest(c *C) {
+	ev := goes.NewEvent("", "SomeEventType", &MyDataType{Field1: 445}, nil)
+	mux.HandleFunc("/streams/Some-Stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ES-CurrentVersion", "unknown")
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	err := client.NewStreamWriter("Some-Stream").Append(goes.Exact(5), ev)
+	c.Assert(err, FitsTypeOf, &goes.ErrBadRequest{})
+}
+
+func (s *StreamWriterSuite) TestAppendInvalidEventsReturnsErrBadRequest(c *C) {
+	ev := goes.NewEvent("", "SomeEventType", &MyDataType{Field1: 445}, nil)
+	mux.HandleFunc("/streams/Some-Stream", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	err := client.NewStreamWriter("Some-Stream").Append(goes.ExpectedVersion{}, ev)
+	c.Assert(err, FitsTypeOf, &goes.ErrBadRequest{})
+}
+
+func (s *StreamWriterSuite) TestAppendSetsExpectedVersion(c *C) {
+	var got string
+	var has bool
+	mux.HandleFunc("/streams/Some-Stream", func(w http.ResponseWriter, r *http.Request) {
+		_, has = r.Header["Es-Expectedversion"]
+		got = r.Header.Get("ES-ExpectedVersion")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	tests := []struct {
+		version goes.ExpectedVersion
+		want    string
+	}{
+		{goes.Any(), "-2"},
+		{goes.NoStream(), "-1"},
+		{goes.EmptyStream(), "-1"},
+		{goes.StreamExists(), "-4"},
+		{goes.Exact(0), "0"},
+		{goes.Exact(7), "7"},
+	}
+	ev := goes.NewEvent("", "SomeEventType", &MyDataType{Field1: 445}, nil)
+	writer := client.NewStreamWriter("Some-Stream")
+	for _, tt := range tests {
+		err := writer.Append(tt.version, ev)
+		c.Assert(err, IsNil)
+		c.Assert(got, Equals, tt.want, Commentf("%s", tt.version))
+	}
+
+	// The zero value is sent without ES-ExpectedVersion.
+	err := writer.Append(goes.ExpectedVersion{}, ev)
+	c.Assert(err, IsNil)
+	c.Assert(has, Equals, false)
+}
+
+func (s *StreamWriterSuite) TestAppendWithInvalidExpectedVersion(c *C) {
+	mux.HandleFunc("/streams/Some-Stream", func(w http.ResponseWriter, r *http.Request) {
+		c.Error("An append with an invalid expected version was sent to the server")
+	})
+
+	ev := goes.NewEvent("", "SomeEventType", &MyDataType{Field1: 445}, nil)
+	writer := client.NewStreamWriter("Some-Stream")
+	err := writer.Append(goes.Exact(-1), ev)
+	c.Assert(err, ErrorMatches, `Invalid expected version Exact\(-1\)\.`)
+
+	writer = client.NewStreamWriter("Some-Stream", goes.WithHeader("ES-ExpectedVersion", "-3"))
+	err = writer.Append(goes.Any(), ev)
+	c.Assert(err, ErrorMatches, `Invalid expected version -3\.`)
+}
+
+func (s *StreamWriterSuite) TestWriteMetaDataWithExpectedVersion(c *C) {
+	stream := "SomeStream"
+	path := fmt.Sprintf("/streams/%s/0/forward/1", stream)
+	fullURL := fmt.Sprintf("%s%s", server.URL, path)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		es := mock.CreateTestEvents(1, stream, server.URL, "MetaData")
+		f, _ := mock.CreateTestFeed(es, fullURL)
+		fmt.Fprint(w, f.PrettyPrint())
+	})
+
+	var got string
+	mux.HandleFunc(fmt.Sprintf("/streams/%s/metadata", stream), func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("ES-ExpectedVersion")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	meta := json.RawMessage(`{"baz":"boo"}`)
+	writer := client.NewStreamWriter(stream)
+	err := writer.WriteMetaData(stream, goes.Exact(3), &meta)
+	c.Assert(err, IsNil)
+	c.Assert(got, Equals, "3")
+
+	got = ""
+	err = writer.WriteMetaData(stream, goes.Exact(-2), &meta)
+	c.Assert(err, ErrorMatches, `Invalid expected version Exact\(-2\)\.`)
+	c.Assert(got, Equals, "")
+}
+
+// Tests that EmptyStream is the same as NoStream, as for the eventstore, and
+// that Exact(0) expects a stream with a single event.
+func (s *StreamWriterSuite) TestEmptyStreamAndExactZero(c *C) {
+	c.Assert(goes.EmptyStream(), Equals, goes.NoStream())
+	c.Assert(goes.EmptyStream().String(), Equals, "NoStream")
+	c.Assert(goes.Exact(0), Not(Equals), goes.EmptyStream())
+	c.Assert(goes.Exact(0).String(), Equals, "Exact(0)")
+
+	mux.HandleFunc("/streams/Some-Stream", func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Header.Get("ES-ExpectedVersion"), Equals, "0")
+		w.Header().Set("ES-CurrentVersion", "-1")
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	ev := goes.NewEvent("", "SomeEventType", &MyDataType{Field1: 445}, nil)
+	err := client.NewStreamWriter("Some-Stream").Append(goes.Exact(0), ev)
+	e, ok := err.(*goes.ErrConcurrencyViolation)
+	c.Assert(ok, Equals, true)
+	c.Assert(e.CurrentVersion, Equals, -1)
 }
 
 func (s *StreamWriterSuite) TestAppendStreamMetadata(c *C) {
@@ -155,7 +301,7 @@ func (s *StreamWriterSuite) TestAppendStreamMetadata(c *C) {
 	})
 
 	writer := client.NewStreamWriter(stream)
-	err := writer.WriteMetaData(stream, &want)
+	err := writer.WriteMetaData(stream, goes.ExpectedVersion{}, &want)
 	c.Assert(err, IsNil)
 }
 
@@ -171,7 +317,7 @@ func (s *StreamWriterSuite) TestAppendStreamMetadataReturnsUnauthorisedWhenGetti
 	want := json.RawMessage(meta)
 
 	writer := client.NewStreamWriter(stream)
-	err := writer.WriteMetaData(stream, &want)
+	err := writer.WriteMetaData(stream, goes.ExpectedVersion{}, &want)
 
 	c.Assert(err, NotNil)
 	if e, ok := err.(*goes.ErrUnauthorized); ok {
@@ -193,7 +339,7 @@ func (s *StreamWriterSuite) TestAppendStreamMetadataReturnsTemporarilyUnavailabl
 	want := json.RawMessage(meta)
 
 	writer := client.NewStreamWriter(stream)
-	err := writer.WriteMetaData(stream, &want)
+	err := writer.WriteMetaData(stream, goes.ExpectedVersion{}, &want)
 
 	c.Assert(err, NotNil)
 	if e, ok := err.(*goes.ErrTemporarilyUnavailable); ok {
@@ -215,7 +361,7 @@ func (s *StreamWriterSuite) TestAppendStreamMetadataReturnsErrUnexpectedWhenGett
 	want := json.RawMessage(meta)
 
 	writer := client.NewStreamWriter(stream)
-	err := writer.WriteMetaData(stream, &want)
+	err := writer.WriteMetaData(stream, goes.ExpectedVersion{}, &want)
 
 	c.Assert(err, NotNil)
 	if e, ok := err.(*goes.ErrUnexpected); ok {
@@ -252,7 +398,7 @@ func (s *StreamWriterSuite) TestAppendStreamMetadataReturnsErrUnexpectedWhenWrit
 	})
 
 	writer := client.NewStreamWriter(stream)
-	err := writer.WriteMetaData(stream, &want)
+	err := writer.WriteMetaData(stream, goes.ExpectedVersion{}, &want)
 
 	c.Assert(err, NotNil)
 	if e, ok := err.(*goes.ErrUnexpected); ok {
@@ -289,7 +435,7 @@ func (s *StreamWriterSuite) TestAppendStreamMetadataReturnsErrUnauthorizedWhenWr
 	})
 
 	writer := client.NewStreamWriter(stream)
-	err := writer.WriteMetaData(stream, &want)
+	err := writer.WriteMetaData(stream, goes.ExpectedVersion{}, &want)
 
 	c.Assert(err, NotNil)
 	if e, ok := err.(*goes.ErrUnauthorized); ok {
@@ -326,7 +472,7 @@ func (s *StreamWriterSuite) TestAppendStreamMetadataReturnsErrTemporarilyUnavail
 	})
 
 	writer := client.NewStreamWriter(stream)
-	err := writer.WriteMetaData(stream, &want)
+	err := writer.WriteMetaData(stream, goes.ExpectedVersion{}, &want)
 
 	c.Assert(err, NotNil)
 	if e, ok := err.(*goes.ErrTemporarilyUnavailable); ok {
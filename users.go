@@ -0,0 +1,199 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// UserDetails describes a user of the eventstore.
+type UserDetails struct {
+	LoginName       string     `json:"loginName"`
+	FullName        string     `json:"fullName"`
+	Groups          []string   `json:"groups"`
+	Disabled        bool       `json:"disabled"`
+	DateLastUpdated TimeStr    `json:"dateLastUpdated,omitempty"`
+	Links           []UserLink `json:"links,omitempty"`
+}
+
+// UserLink encapsulates the urls returned with user details.
+type UserLink struct {
+	Href string `json:"href"`
+	Rel  string `json:"rel"`
+}
+
+// userResponse is used internally to unmarshal the response envelope for
+// a single user.
+type userResponse struct {
+	Data    *UserDetails `json:"data"`
+	Success bool         `json:"success"`
+	Error   string       `json:"error"`
+}
+
+// usersResponse is used internally to unmarshal the response envelope for
+// a list of users.
+type usersResponse struct {
+	Data    []*UserDetails `json:"data"`
+	Success bool           `json:"success"`
+	Error   string         `json:"error"`
+}
+
+// UserService provides methods for managing eventstore users.
+//
+// Requests made by the UserService are authenticated with the client's
+// Authenticator. Most operations require the credentials of a member of
+// the $admins group.
+//
+// If a user does not exist an ErrNotFound is returned, if the client is not
+// authorised to perform the operation an ErrUnauthorized is returned and if
+// a user is created with a login name that is already in use an ErrConflict
+// is returned.
+//
+// For more information on user management see:
+// http://docs.geteventstore.com/server/3.8.0/users-and-access-control-lists/
+type UserService struct {
+	client *Client
+}
+
+// Users returns a *UserService for managing eventstore users.
+func (c *Client) Users() *UserService {
+	return &UserService{client: c}
+}
+
+// Create creates a new user.
+func (u *UserService) Create(loginName, fullName, password string, groups []string, opts ...RequestOption) (*Response, error) {
+	body := struct {
+		LoginName string   `json:"loginName"`
+		FullName  string   `json:"fullName"`
+		Groups    []string `json:"groups"`
+		Password  string   `json:"password"`
+	}{loginName, fullName, groups, password}
+
+	return u.send(http.MethodPost, "/users/", body, opts)
+}
+
+// List returns the details of all users.
+func (u *UserService) List(opts ...RequestOption) ([]*UserDetails, *Response, error) {
+	var b bytes.Buffer
+	resp, err := u.get("/users/", &b, opts)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	ur := &usersResponse{}
+	if err := json.NewDecoder(&b).Decode(ur); err != nil {
+		return nil, resp, err
+	}
+
+	return ur.Data, resp, nil
+}
+
+// Get returns the details of a single user.
+func (u *UserService) Get(loginName string, opts ...RequestOption) (*UserDetails, *Response, error) {
+	var b bytes.Buffer
+	resp, err := u.get(userPath(loginName), &b, opts)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	ur := &userResponse{}
+	if err := json.NewDecoder(&b).Decode(ur); err != nil {
+		return nil, resp, err
+	}
+
+	return ur.Data, resp, nil
+}
+
+// Update replaces the full name and groups of a user.
+func (u *UserService) Update(loginName, fullName string, groups []string, opts ...RequestOption) (*Response, error) {
+	body := struct {
+		FullName string   `json:"fullName"`
+		Groups   []string `json:"groups"`
+	}{fullName, groups}
+
+	return u.send(http.MethodPut, userPath(loginName), body, opts)
+}
+
+// Enable enables a user that has been disabled.
+func (u *UserService) Enable(loginName string, opts ...RequestOption) (*Response, error) {
+	return u.send(http.MethodPost, userPath(loginName)+"/command/enable", nil, opts)
+}
+
+// Disable disables a user. A disabled user cannot authenticate with the eventstore.
+func (u *UserService) Disable(loginName string, opts ...RequestOption) (*Response, error) {
+	return u.send(http.MethodPost, userPath(loginName)+"/command/disable", nil, opts)
+}
+
+// ResetPassword sets a new password for a user without requiring the
+// current password.
+func (u *UserService) ResetPassword(loginName, newPassword string, opts ...RequestOption) (*Response, error) {
+	body := struct {
+		NewPassword string `json:"newPassword"`
+	}{newPassword}
+
+	return u.send(http.MethodPost, userPath(loginName)+"/command/reset-password", body, opts)
+}
+
+// ChangePassword changes the password of a user.
+func (u *UserService) ChangePassword(loginName, currentPassword, newPassword string, opts ...RequestOption) (*Response, error) {
+	body := struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}{currentPassword, newPassword}
+
+	return u.send(http.MethodPost, userPath(loginName)+"/command/change-password", body, opts)
+}
+
+// Delete deletes a user.
+func (u *UserService) Delete(loginName string, opts ...RequestOption) (*Response, error) {
+	return u.send(http.MethodDelete, userPath(loginName), nil, opts)
+}
+
+func (u *UserService) get(path string, b *bytes.Buffer, opts []RequestOption) (*Response, error) {
+	req, err := u.client.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	applyOptions(req, opts)
+
+	resp, err := u.client.Do(req, b)
+	return resp, userError(err)
+}
+
+func (u *UserService) send(method, path string, body interface{}, opts []RequestOption) (*Response, error) {
+	req, err := u.client.NewRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	applyOptions(req, opts)
+
+	resp, err := u.client.Do(req, nil)
+	return resp, userError(err)
+}
+
+// userError returns an *ErrConflict for a conflict returned by the user
+// service. Conflicts returned for other requests are left as an
+// *ErrUnexpected.
+func userError(err error) error {
+	if e, ok := err.(*ErrUnexpected); ok && e.ErrorResponse.StatusCode == http.StatusConflict {
+		return &ErrConflict{ErrorResponse: e.ErrorResponse}
+	}
+	return err
+}
+
+func userPath(loginName string) string {
+	return fmt.Sprintf("/users/%s", url.PathEscape(loginName))
+}
@@ -0,0 +1,176 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/jetbasrawi/go.geteventstore"
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&UsersSuite{})
+
+type UsersSuite struct{}
+
+func (s *UsersSuite) SetUpTest(c *C) {
+	setup()
+}
+func (s *UsersSuite) TearDownTest(c *C) {
+	teardown()
+}
+
+const userJSON = `{
+  "loginName": "ouro",
+  "fullName": "Ouro Boros",
+  "groups": ["$admins", "ops"],
+  "disabled": false,
+  "dateLastUpdated": "2016-06-29T10:21:40.7306667Z",
+  "links": [{"href": "http://localhost:2113/users/ouro", "rel": "edit"}]
+}`
+
+func (s *UsersSuite) TestCreateUser(c *C) {
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodPost)
+		c.Assert(r.Header.Get("Content-Type"), Equals, "application/json")
+
+		got := make(map[string]interface{})
+		err := json.NewDecoder(r.Body).Decode(&got)
+		c.Assert(err, IsNil)
+		c.Assert(got, DeepEquals, map[string]interface{}{
+			"loginName": "ouro",
+			"fullName":  "Ouro Boros",
+			"groups":    []interface{}{"ops"},
+			"password":  "changeit",
+		})
+
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	resp, err := client.Users().Create("ouro", "Ouro Boros", "changeit", []string{"ops"})
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusCreated)
+}
+
+func (s *UsersSuite) TestCreateExistingUserReturnsErrConflict(c *C) {
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+
+	_, err := client.Users().Create("ouro", "Ouro Boros", "changeit", nil)
+	c.Assert(reflect.TypeOf(err).Elem().Name(), Equals, "ErrConflict")
+}
+
+func (s *UsersSuite) TestListUsers(c *C) {
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodGet)
+		c.Assert(r.Header.Get("Accept"), Equals, "application/json")
+		fmt.Fprintf(w, `{"data": [%s], "success": true, "error": "Success"}`, userJSON)
+	})
+
+	users, _, err := client.Users().List()
+	c.Assert(err, IsNil)
+	c.Assert(users, HasLen, 1)
+	c.Assert(users[0].LoginName, Equals, "ouro")
+	c.Assert(users[0].Groups, DeepEquals, []string{"$admins", "ops"})
+}
+
+func (s *UsersSuite) TestGetUser(c *C) {
+	mux.HandleFunc("/users/ouro", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodGet)
+		fmt.Fprintf(w, `{"data": %s, "success": true, "error": "Success"}`, userJSON)
+	})
+
+	user, _, err := client.Users().Get("ouro")
+	c.Assert(err, IsNil)
+	c.Assert(user, DeepEquals, &goes.UserDetails{
+		LoginName:       "ouro",
+		FullName:        "Ouro Boros",
+		Groups:          []string{"$admins", "ops"},
+		DateLastUpdated: "2016-06-29T10:21:40.7306667Z",
+		Links:           []goes.UserLink{{Href: "http://localhost:2113/users/ouro", Rel: "edit"}},
+	})
+}
+
+func (s *UsersSuite) TestGetUserThatDoesNotExistReturnsErrNotFound(c *C) {
+	mux.HandleFunc("/users/nobody", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	user, _, err := client.Users().Get("nobody")
+	c.Assert(user, IsNil)
+	c.Assert(reflect.TypeOf(err).Elem().Name(), Equals, "ErrNotFound")
+}
+
+func (s *UsersSuite) TestGetUserUnauthorizedReturnsErrUnauthorized(c *C) {
+	mux.HandleFunc("/users/ouro", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	user, _, err := client.Users().Get("ouro")
+	c.Assert(user, IsNil)
+	c.Assert(reflect.TypeOf(err).Elem().Name(), Equals, "ErrUnauthorized")
+}
+
+func (s *UsersSuite) TestUpdateUser(c *C) {
+	mux.HandleFunc("/users/ouro", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodPut)
+
+		got := make(map[string]interface{})
+		err := json.NewDecoder(r.Body).Decode(&got)
+		c.Assert(err, IsNil)
+		c.Assert(got, DeepEquals, map[string]interface{}{
+			"fullName": "Ouro",
+			"groups":   []interface{}{"$admins"},
+		})
+	})
+
+	_, err := client.Users().Update("ouro", "Ouro", []string{"$admins"})
+	c.Assert(err, IsNil)
+}
+
+func (s *UsersSuite) TestUserCommands(c *C) {
+	var gotPath string
+	var gotBody map[string]interface{}
+	mux.HandleFunc("/users/ouro/command/", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodPost)
+		gotPath = r.URL.Path
+		gotBody = nil
+		json.NewDecoder(r.Body).Decode(&gotBody)
+	})
+
+	users := client.Users()
+
+	_, err := users.Enable("ouro")
+	c.Assert(err, IsNil)
+	c.Assert(gotPath, Equals, "/users/ouro/command/enable")
+
+	_, err = users.Disable("ouro")
+	c.Assert(err, IsNil)
+	c.Assert(gotPath, Equals, "/users/ouro/command/disable")
+
+	_, err = users.ResetPassword("ouro", "new")
+	c.Assert(err, IsNil)
+	c.Assert(gotPath, Equals, "/users/ouro/command/reset-password")
+	c.Assert(gotBody, DeepEquals, map[string]interface{}{"newPassword": "new"})
+
+	_, err = users.ChangePassword("ouro", "old", "new")
+	c.Assert(err, IsNil)
+	c.Assert(gotPath, Equals, "/users/ouro/command/change-password")
+	c.Assert(gotBody, DeepEquals, map[string]interface{}{"currentPassword": "old", "newPassword": "new"})
+}
+
+func (s *UsersSuite) TestDeleteUser(c *C) {
+	mux.HandleFunc("/users/ouro", func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, http.MethodDelete)
+	})
+
+	_, err := client.Users().Delete("ouro")
+	c.Assert(err, IsNil)
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 Jet Basrawi. All rights reserved.
+//
+// Use of this source code is governed by a permissive BSD 3 Clause License
+// that can be found in the license file.
+
+package goes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ExpectedVersion is the version a stream is expected to be at when it is
+// written to. If the stream is not at the expected version the write fails
+// with an *ErrConcurrencyViolation.
+//
+// For more information see:
+// http://docs.geteventstore.com/http-api/3.7.0/writing-to-a-stream/
+//
+// The zero value makes no assertion about the version of the stream and is
+// sent to the server without ES-ExpectedVersion, which the server treats the
+// same as Any.
+type ExpectedVersion struct {
+	version int
+	exact   bool
+	set     bool
+}
+
+// Any returns an ExpectedVersion with which a write never conflicts.
+func Any() ExpectedVersion {
+	return ExpectedVersion{version: -2, set: true}
+}
+
+// NoStream returns an ExpectedVersion with which a write succeeds only if the
+// stream does not exist. The write creates the stream.
+func NoStream() ExpectedVersion {
+	return ExpectedVersion{version: -1, set: true}
+}
+
+// EmptyStream returns an ExpectedVersion with which a write succeeds only if the
+// stream does not exist or has no events.
+//
+// The eventstore uses -1 for an empty stream, so EmptyStream is the same as
+// NoStream and both are sent as -1.
+func EmptyStream() ExpectedVersion {
+	return NoStream()
+}
+
+// StreamExists returns an ExpectedVersion with which a write succeeds only if
+// the stream exists, whatever its version.
+func StreamExists() ExpectedVersion {
+	return ExpectedVersion{version: -4, set: true}
+}
+
+// Exact returns an ExpectedVersion with which a write succeeds only if the last
+// event of the stream is event number n. n must not be negative.
+//
+// Exact(0) expects the stream to contain a single event. Use EmptyStream for a
+// stream without events.
+func Exact(n int) ExpectedVersion {
+	return ExpectedVersion{version: n, exact: true, set: true}
+}
+
+// String returns the name of the expected version.
+func (v ExpectedVersion) String() string {
+	switch {
+	case !v.set:
+		return "None"
+	case v.exact:
+		return fmt.Sprintf("Exact(%d)", v.version)
+	case v.version == -2:
+		return "Any"
+	case v.version == -1:
+		return "NoStream"
+	case v.version == -4:
+		return "StreamExists"
+	}
+	return strconv.Itoa(v.version)
+}
+
+// validate returns an error if the expected version is not valid.
+func (v ExpectedVersion) validate() error {
+	if v.exact && v.version < 0 {
+		return fmt.Errorf("Invalid expected version %s.", v)
+	}
+	return nil
+}
+
+// setExpectedVersion sets ES-ExpectedVersion on the request for a valid
+// expected version. The zero value leaves the request without it.
+func setExpectedVersion(req *http.Request, v ExpectedVersion) {
+	if v.set {
+		req.Header.Set("ES-ExpectedVersion", strconv.Itoa(v.version))
+	}
+}
+
+// checkExpectedVersion returns an error if the request has an
+// ES-ExpectedVersion, set with an option, that is not a valid expected version.
+func checkExpectedVersion(req *http.Request) error {
+	h := req.Header.Get("ES-ExpectedVersion")
+	if h == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(h)
+	if err != nil || n < -2 && n != -4 {
+		return fmt.Errorf("Invalid expected version %s.", h)
+	}
+	return nil
+}